@@ -5,6 +5,7 @@
 package anvil
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
@@ -13,22 +14,63 @@ import (
 )
 
 type (
-	// mode of (non-)skipping empty values
-	mode int
+	// Mode of (non-)skipping empty values
+	Mode int
 	// Anvil executor structure
 	Anvil struct {
 		//Mode behavior for skipping empty values
-		Mode mode
+		Mode Mode
 		//Glue string to glue fields
 		Glue string
+		// Formatter of keys, BracketFormatter with a Glue is used if not set
+		Formatter KeyFormatter
+		// Escaping of glue and brackets in names and keys of default Formatter
+		Escaping Escaping
+		// Root prefix of keys instead of a type name
+		Root string
+		// OmitRoot prefix of keys, keys start with a first field name
+		OmitRoot bool
+		// Tags of structure fields consulted in order, `json` by default
+		Tags []string
+		// FlattenEmbedded promotes fields of embedded structures to a parent level
+		FlattenEmbedded bool
+		// Unexported fields policy, UnexportedInclude by default
+		Unexported UnexportedPolicy
+		// Kinds policies of channels, functions and unsafe pointers, KindError by default
+		Kinds map[reflect.Kind]KindPolicy
+		// MapLess ordering of map keys of the same type, natural ordering of a key kind by default
+		MapLess func(a, b reflect.Value) bool
+		// Markers of nil and empty slices, maps and pointers in NoSkipEmpty mode
+		Markers Markers
+		// Cycles policy of values referencing own ancestors, CycleError by default
+		Cycles CyclePolicy
 		// modifier it's a list of functions used as a rule
 		// to find out empty or not empty value of a field with given type and
 		// type representation
-		// exported type key as a key and list of functions to execute.
-		modifier map[string]func(f reflect.Value) (interface{}, bool, error)
+		// type as a key and list of functions to execute.
+		modifier map[reflect.Type]modifierFunc
+		// qualified names of named types with modifiers (`path/to/pkg.Name`), a fallback of lookups
+		qualified map[string]reflect.Type
+		// implementers modifiers of interfaces in order of registration
+		implementers []implementer
+		// paths modifiers of path patterns in order of registration
+		paths []pathModifier
+		// err of a modifier registration returned by notation
+		err error
+		// MaxDepth of nested containers, deeper containers are truncated, unlimited if 0
+		MaxDepth int
+		// Truncate policy of containers deeper than MaxDepth, TruncateValue by default
+		Truncate TruncatePolicy
+		// Include only paths matching glob patterns (`User.Address.*`, `Orders[*].SKU`, `**.Name`)
+		Include []string
+		// Exclude paths matching glob patterns, excluded values are not visited
+		Exclude []string
+		// MaxIndex of slices grown by Unnotation, DefaultMaxIndex if 0
+		MaxIndex int
+		// empties predicates of types
+		empties map[reflect.Type]func(f reflect.Value) bool
 		// collection of []{key => value}
 		items []Item
-		deep  int // reserved for a future features
 	}
 	// Item field with typed value as a result of notation
 	Item struct {
@@ -39,7 +81,7 @@ type (
 
 const (
 	// NoSkipEmpty fields with empty values
-	NoSkipEmpty mode = iota
+	NoSkipEmpty Mode = iota
 	// SkipEmpty fields with empty values
 	SkipEmpty
 )
@@ -48,27 +90,46 @@ const (
 // to extract value of given type as
 // result of callback function used (value, isEmpty, error) where
 // value is an interface{} value, isEmpty - valuable for
-// behaviour Mode, and error if error occurred, used to stop execution
+// behaviour Mode, and error if error occurred, used to stop execution,
+// distinct types with the same qualified name are ambiguous, an error is returned by notation
 func (s *Anvil) RegisterModifierFunc(t interface{}, mod func(f reflect.Value) (interface{}, bool, error)) *Anvil {
+	return s.register(t, plain(mod))
+}
+
+// register a modifier of a type
+func (s *Anvil) register(t interface{}, mod modifierFunc) *Anvil {
+	typ := reflect.TypeOf(t)
+	if typ == nil {
+		return s.fail(errors.New("anvil:modifier of a nil type"))
+	}
 	if s.modifier == nil {
-		s.modifier = make(map[string]func(f reflect.Value) (interface{}, bool, error))
+		s.modifier = make(map[reflect.Type]modifierFunc)
 	}
-	s.modifier[reflect.TypeOf(t).String()] = mod
+	if name, ok := qualified(typ); ok {
+		if s.qualified == nil {
+			s.qualified = make(map[string]reflect.Type)
+		}
+		if other, ok := s.qualified[name]; ok && other != typ {
+			return s.fail(errors.New("anvil:ambiguous modifiers of " + name))
+		}
+		s.qualified[name] = typ
+	}
+	s.modifier[typ] = mod
 	return s
 }
 
 // Notation of go type as a list of []Item
 // where key is a string and value is a typed interface value
-func Notation(source interface{}, behaviour mode, glue string) ([]Item, error) {
+func Notation(source interface{}, behaviour Mode, glue string) ([]Item, error) {
 	if source == nil {
 		return nil, nil
 	}
 	s := &Anvil{
-		Glue:     glue,
-		Mode:     behaviour,
-		modifier: make(map[string]func(f reflect.Value) (interface{}, bool, error)),
+		Glue: glue,
+		Mode: behaviour,
 	}
-	return s.notation("", reflect.ValueOf(source), false)
+	v := reflect.ValueOf(source)
+	return s.start(s.root(v.Type()), v)
 }
 
 // Notation of go type as a list of []Item
@@ -77,38 +138,100 @@ func (s *Anvil) Notation(sample interface{}) ([]Item, error) {
 	if sample == nil {
 		return nil, nil
 	}
-	return s.notation("", reflect.ValueOf(sample), false)
+	v := reflect.ValueOf(sample)
+	return s.start(s.root(v.Type()), v)
+}
+
+// NotationWithKey of go type as a list of []Item
+// where keys are prefixed with a given key instead of a type name,
+// a root prefix is used for an empty key
+func (s *Anvil) NotationWithKey(key string, sample interface{}) ([]Item, error) {
+	if sample == nil {
+		return nil, nil
+	}
+	v := reflect.ValueOf(sample)
+	if len(key) < 1 {
+		key = s.root(v.Type())
+	}
+	return s.start(key, v)
 }
 
-// notation structure nested
-func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item, err error) {
+// start notation of a value by a root key
+func (s *Anvil) start(key string, v reflect.Value) ([]Item, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	path, err := s.trail(key)
+	if err != nil {
+		return nil, err
+	}
+	return s.notation(key, v, Tag{}, path)
+}
+
+// notation structure nested, omitempty of a tag is inherited by nested values,
+// containers on a path are kept by a trail to detect cycles
+func (s *Anvil) notation(key string, v reflect.Value, tag Tag, path trail) (items []Item, err error) {
 	var (
 		value interface{}
 		empty = true
-		skip  = s.Mode == SkipEmpty
+		skip  = s.Mode == SkipEmpty || tag.OmitEmpty
+		inner = Tag{OmitEmpty: tag.OmitEmpty}
 	)
-	// get value by pointer if it is
-	v = reflect.Indirect(v)
-
-	// set default prefix for a field
-	if len(key) < 1 {
-		key = v.Type().Name()
+	// get value by pointers if it is, nil pointers without a marker are skipped
+	if v.Kind() == reflect.Ptr {
+		if v = indirect(v); !v.IsValid() && s.Markers.NilPointer == nil {
+			return nil, nil
+		}
+	}
+	if !path.visit() {
+		return nil, nil
 	}
+	// modified values of any kind are not walked
+	if value, empty, ok, err := s.modify(key, v, path); ok {
+		if err != nil || !path.included || empty && skip {
+			return nil, err
+		}
+		if items, ok := value.(Items); ok {
+			return expand(path.format, key, items, skip), nil
+		}
+		return append(s.items, Item{Key: key, Value: value}), nil
+	}
+	// emptiness defined by a predicate or IsZero method of a type, only skipped values are checked
+	var zero, defined bool
+	if skip {
+		if zero, defined = s.zero(v); defined && zero {
+			return nil, nil
+		}
+	}
+	if s.truncated(v, path) {
+		if !path.included {
+			return nil, nil
+		}
+		return s.truncate(key, v, skip), nil
+	}
+	if id, ok := identity(v); ok {
+		if first, ok := path.visited[id]; ok {
+			if !path.included {
+				return nil, nil
+			}
+			return s.cycle(path.format, key, first)
+		}
+		path.visited[id] = key
+		defer delete(path.visited, id)
+	}
+
 	switch v.Kind() {
 	case reflect.Invalid:
-		return nil, errors.New("anvil:invalid value of " + v.Type().Name())
+		if s.Markers.NilPointer == nil {
+			return nil, errors.New("anvil:invalid value of " + key)
+		}
+		value = s.Markers.NilPointer
 	case reflect.Array:
 		if v.Len() < 1 {
 			break
 		}
-		if value, empty, err = s.modify(v); err != nil {
-			break
-		}
-		if !empty {
-			break
-		}
 		for i := 0; i < v.Len(); i++ {
-			n, err := s.notation(arrayPrefix(key, i), v.Index(i), true)
+			n, err := s.notation(path.format.Index(key, i), v.Index(i), inner, path.nested(index(i), v))
 			if err != nil {
 				return nil, err
 			}
@@ -119,17 +242,16 @@ func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item,
 		}
 	case reflect.Slice:
 		if v.IsNil() {
-			break
-		}
-		if value, empty, err = s.modify(v); err != nil {
+			value = s.Markers.NilSlice
 			break
 		}
 		if v.Len() < 1 {
+			value = s.Markers.EmptySlice
 			break
 		}
 		for i := 0; i < v.Len(); i++ {
 			if v.Index(i).CanAddr() {
-				n, err := s.notation(arrayPrefix(key, i), reflect.Indirect(v.Index(i).Addr()), true)
+				n, err := s.notation(path.format.Index(key, i), reflect.Indirect(v.Index(i).Addr()), inner, path.nested(index(i), v))
 				if err != nil {
 					return nil, err
 				}
@@ -140,20 +262,21 @@ func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item,
 			}
 		}
 	case reflect.Struct:
-		if value, empty, err = s.modify(v); err != nil {
-			return nil, err
-		}
-		if !empty {
-			break
-		}
-		l := v.NumField()
-		for i := 0; i < l; i++ {
-			f := reflect.Indirect(v.Field(i))
-			// skip invalid field
-			if f.Kind() == reflect.Invalid {
+		// structures without items of fields are empty, skipped entirely in SkipEmpty mode
+		for _, field := range s.fields(v.Type()) {
+			f := fieldByIndex(v, field.index)
+			// skip fields promoted through a nil embedded pointer, and nil pointers without a marker
+			if !f.IsValid() || !indirect(f).IsValid() && s.Markers.NilPointer == nil {
 				continue
 			}
-			n, err := s.notation(s.key(key, v.Type().Field(i), false), f, true)
+			t := field.tag
+			t.OmitEmpty = t.OmitEmpty || tag.OmitEmpty
+			next := path.nested(Segment{Name: t.Name}, v)
+			if t.Inline {
+				next = path
+			}
+			next.parent, next.index = v, field.index
+			n, err := s.notation(fieldKey(path.format, key, t), f, t, next)
 			if err != nil {
 				return nil, err
 			}
@@ -166,7 +289,7 @@ func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item,
 		if !v.Elem().IsValid() {
 			break
 		}
-		n, err := s.notation(key, v.Elem(), true)
+		n, err := s.notation(key, v.Elem(), tag, path)
 		if err != nil {
 			return nil, err
 		}
@@ -203,12 +326,20 @@ func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item,
 	case reflect.String:
 		value, empty = v.String(), len(v.String()) < 1
 	case reflect.Map:
-		if v.IsNil() || v.Len() < 1 {
+		if v.IsNil() {
+			value = s.Markers.NilMap
+			break
+		}
+		if v.Len() < 1 {
+			value = s.Markers.EmptyMap
 			break
 		}
-		keys := v.MapKeys()
-		for i := range keys {
-			n, err := s.notation(mapPrefix(key, keys[i]), v.MapIndex(keys[i]), true)
+		for _, e := range s.entries(v) {
+			k, err := mapKey(e.key)
+			if err != nil {
+				return nil, fmt.Errorf("anvil:map key of %s: %v", key, err)
+			}
+			n, err := s.notation(path.format.MapKey(key, k), e.value, inner, path.nested(Segment{Name: k, Bracket: true}, v))
 			if err != nil {
 				return nil, err
 			}
@@ -223,76 +354,201 @@ func (s *Anvil) notation(key string, v reflect.Value, title bool) (items []Item,
 	case reflect.Complex128:
 		value = v.Complex()
 		empty = reflect.Zero(v.Type()).Complex() == value
-	case reflect.Uintptr, reflect.Ptr, reflect.UnsafePointer:
-		fallthrough
+	case reflect.Uintptr:
+		value, empty = uintptr(v.Uint()), v.Uint() == 0
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		switch s.Kinds[v.Kind()] {
+		case KindSkip:
+			return nil, nil
+		case KindNil:
+		case KindType:
+			value, empty = v.Type().String(), v.IsNil()
+		default:
+			return nil, errors.New("anvil:not implemented for " + v.Kind().String() + " of " + key)
+		}
 	default:
 		return nil, errors.New("anvil:not implemented for " + v.Kind().String())
 	}
 	if len(items) > 0 {
 		return items, err
 	}
+	// values on a way to included paths
+	if !path.included {
+		return nil, err
+	}
+	if defined && value != nil {
+		empty = zero
+	}
 	if empty && skip {
 		return nil, err
 	}
+	if tag.String && value != nil && scalar(v.Kind()) {
+		value = fmt.Sprint(value)
+	}
 	return append(s.items, Item{Key: key, Value: value}), err
 }
 
-// modify - call modifier function if presented for a given type
-func (s *Anvil) modify(v reflect.Value) (interface{}, bool, error) {
-	var err error
+// modify - call modifier function if presented for a path, a type of value or an implemented interface,
+// ok is false if there is no modifier
+func (s *Anvil) modify(key string, v reflect.Value, path trail) (value interface{}, empty, ok bool, err error) {
+	fn, v, ok := s.modifierOf(v, path)
+	if !ok {
+		return nil, true, false, nil
+	}
 	defer func() {
 		if r := recover(); r != nil {
 			err = fmt.Errorf("anvil: %v on appendix call", r)
 		}
 	}()
-	if fn, ok := s.modifier[v.Type().String()]; ok {
-		return fn(v)
+	value, empty, err = fn(path.context(s, key), v)
+	return value, empty, true, err
+}
+
+// root prefix of keys for a type, Root or a name of a type
+// without type arguments (`List` for `List[int]`)
+func (s *Anvil) root(t reflect.Type) string {
+	if s.OmitRoot {
+		return ""
+	}
+	if len(s.Root) > 0 {
+		return s.Root
 	}
-	return nil, true, err
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	name := t.Name()
+	if i := strings.IndexByte(name, '['); i > -1 {
+		name = name[:i]
+	}
+	return name
 }
 
-// key of field
-func (s *Anvil) key(pref string, v reflect.StructField, omit bool) string {
-	if omit {
+// fieldKey of a structure field by a formatter, inlined field keeps a prefix
+func fieldKey(f KeyFormatter, pref string, tag Tag) string {
+	if tag.Inline {
 		return pref
 	}
-	var title string
-	json, ok := v.Tag.Lookup("json")
-	if !ok || len(json) < 1 {
-		title = v.Name
-	} else {
-		tags := strings.Split(json, ",")
-		if len(tags[0]) > 1 || tags[0] != "-" {
-			title = tags[0]
+	return f.Field(pref, tag.Name)
+}
+
+// fieldByIndex of a structure, invalid value for a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, idx := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}
+			}
+			v = v.Elem()
 		}
+		v = v.Field(idx)
 	}
-	if len(title) < 1 {
-		title = v.Name
+	return v
+}
+
+// scalar kind of a value with a string representation
+func scalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return true
 	}
-	return pref + s.Glue + title
+	return false
+}
+
+// index segment of an array or a slice element
+func index(i int) Segment {
+	return Segment{Name: strconv.Itoa(i), Bracket: true}
 }
 
+// nilKey text of a nil interface key
+const nilKey = "<nil>"
+
 // arrayPrefix - make a notation prefix for a slice/array fields
 func arrayPrefix(pref string, idx int) string {
 	return pref + "[" + strconv.Itoa(idx) + "]"
 }
 
-// mapPrefix - make a notation prefix for a map fields
-func mapPrefix(pref string, idx reflect.Value) string {
-	var val string
+// mapKey - make a string representation of a map key,
+// composite keys (structures and arrays) are represented as `{a,b}`,
+// parts of composite keys with commas, braces or quotes are quoted as `{"a,b",c}`,
+// strings of interface keys looking like `<nil>` or quoted are quoted too
+func mapKey(idx reflect.Value) (string, error) {
+	key, _, err := keyText(idx)
+	return key, err
+}
+
+// keyText of a map key, verbatim text of a composite key, a nil or a quoted text is never quoted
+func keyText(idx reflect.Value) (text string, verbatim bool, err error) {
+	if idx.Kind() == reflect.String {
+		return idx.String(), false, nil
+	}
+	if idx.CanInterface() {
+		if m, ok := idx.Interface().(encoding.TextMarshaler); ok {
+			text, err := m.MarshalText()
+			return string(text), false, err
+		}
+	}
 	switch idx.Kind() {
-	case reflect.String:
-		val = idx.String()
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		val = strconv.FormatInt(idx.Int(), 10)
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		val = strconv.FormatUint(idx.Uint(), 10)
+		return strconv.FormatInt(idx.Int(), 10), false, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(idx.Uint(), 10), false, nil
 	case reflect.Float32:
-		val = strconv.FormatFloat(idx.Float(), 'f', -1, 32)
+		return strconv.FormatFloat(idx.Float(), 'f', -1, 32), false, nil
 	case reflect.Float64:
-		val = strconv.FormatFloat(idx.Float(), 'f', -1, 64)
+		return strconv.FormatFloat(idx.Float(), 'f', -1, 64), false, nil
 	case reflect.Bool:
-		val = strconv.FormatBool(idx.Bool())
+		return strconv.FormatBool(idx.Bool()), false, nil
+	}
+	if idx.CanInterface() {
+		if m, ok := idx.Interface().(fmt.Stringer); ok {
+			return m.String(), false, nil
+		}
+	}
+	switch idx.Kind() {
+	case reflect.Complex64, reflect.Complex128:
+		return fmt.Sprint(idx.Complex()), false, nil
+	case reflect.Interface:
+		if idx.IsNil() {
+			return nilKey, true, nil
+		}
+		text, verbatim, err := keyText(idx.Elem())
+		if !verbatim && (text == nilKey || strings.HasPrefix(text, `"`)) {
+			return strconv.Quote(text), true, err
+		}
+		return text, verbatim, err
+	case reflect.Struct, reflect.Array:
+		elems := elements(idx)
+		parts := make([]string, len(elems))
+		for i := range elems {
+			part, verbatim, err := keyText(elems[i])
+			if err != nil {
+				return "", false, err
+			}
+			if !verbatim && (part == nilKey || strings.ContainsAny(part, `,{}"`)) {
+				part = strconv.Quote(part)
+			}
+			parts[i] = part
+		}
+		return "{" + strings.Join(parts, ",") + "}", true, nil
+	}
+	return "", false, errors.New("no representation for a key of " + idx.Type().String())
+}
+
+// elements of a structure (fields) or an array
+func elements(v reflect.Value) []reflect.Value {
+	var elems []reflect.Value
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			elems = append(elems, v.Field(i))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			elems = append(elems, v.Index(i))
+		}
 	}
-	return pref + "[" + val + "]"
+	return elems
 }
@@ -39,3 +39,27 @@ func BenchmarkNotation_WithForceSkip(b *testing.B) {
 
 	trash = r
 }
+
+func BenchmarkNotation_Document(b *testing.B) {
+	var r interface{}
+	v := Config{
+		Name:     "service",
+		Port:     8080,
+		Debug:    true,
+		Ratio:    .5,
+		Tags:     []string{"one", "two", "three"},
+		Weights:  [3]int{1, 2, 3},
+		Limits:   map[string]int64{"cpu": 2, "memory": 512},
+		Codes:    map[int]string{-1: "minus", 1: "plus"},
+		Backends: []*Backend{{Host: "a", Port: 1}, {Host: "b", Port: 2}},
+		Primary:  &Backend{Host: "p", Port: 3},
+		Extra:    "extra",
+	}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: "."}
+
+	for n := 0; n < b.N; n++ {
+		r, _ = a.Notation(v)
+	}
+
+	trash = r
+}
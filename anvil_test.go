@@ -6,6 +6,7 @@ package anvil
 
 import (
 	"reflect"
+	"strconv"
 	"testing"
 	"time"
 
@@ -81,8 +82,12 @@ func TestMapPrefix_WithIntKey(t *testing.T) {
 	i := int(1)
 	k := reflect.ValueOf(i)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[1]" {
 		t.Error("invalid value of map prefix for int key value")
 	}
@@ -92,8 +97,12 @@ func TestMapPrefix_WithStringKey(t *testing.T) {
 	i := "key"
 	k := reflect.ValueOf(i)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[key]" {
 		t.Error("invalid value of map prefix for string key value")
 	}
@@ -103,8 +112,12 @@ func TestMapPrefix_WithUintKey(t *testing.T) {
 	i := uint(2)
 	k := reflect.ValueOf(i)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[2]" {
 		t.Error("invalid value of map prefix for string key value")
 	}
@@ -114,8 +127,12 @@ func TestMapPrefix_WithFloat32Key(t *testing.T) {
 	i := float32(.1)
 	k := reflect.ValueOf(i)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[0.1]" {
 		t.Error("invalid value of map prefix for string key value")
 	}
@@ -125,8 +142,12 @@ func TestMapPrefix_WithFloat64Key(t *testing.T) {
 	i := float64(.2)
 	k := reflect.ValueOf(i)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[0.2]" {
 		t.Error("invalid value of map prefix for string key value")
 	}
@@ -135,13 +156,68 @@ func TestMapPrefix_WithFloat64Key(t *testing.T) {
 func TestMapPrefix_WithBool(t *testing.T) {
 	k := reflect.ValueOf(true)
 
-	pref := mapPrefix("", k)
+	key, err := mapKey(k)
+	pref := BracketFormatter{}.MapKey("", key)
 
+	if err != nil {
+		t.Error(err)
+	}
 	if pref != "[true]" {
 		t.Error("invalid value of map prefix for string key value")
 	}
 }
 
+type (
+	textKey  struct{ a, b int }
+	stringer struct{ name string }
+)
+
+func (k textKey) MarshalText() ([]byte, error) {
+	return []byte(strconv.Itoa(k.a) + "-" + strconv.Itoa(k.b)), nil
+}
+
+func (k stringer) String() string {
+	return "<" + k.name + ">"
+}
+
+func TestMapKey_WithCompositeKeys(t *testing.T) {
+	type Point struct {
+		X, Y int
+		Tag  interface{}
+	}
+	cases := []struct {
+		key      interface{}
+		expected string
+	}{
+		{key: textKey{a: 1, b: 2}, expected: "1-2"},
+		{key: stringer{name: "key"}, expected: "<key>"},
+		{key: Point{X: 1, Y: -2, Tag: "a"}, expected: "{1,-2,a}"},
+		{key: [2]Point{{X: 1}, {Y: 1, Tag: true}}, expected: "{{1,0,<nil>},{0,1,true}}"},
+		{key: complex(1, 2), expected: "(1+2i)"},
+	}
+	for _, c := range cases {
+		occurred, err := mapKey(reflect.ValueOf(c.key))
+
+		if err != nil {
+			t.Error(err)
+		}
+		if occurred != c.expected {
+			t.Errorf("expected %q, occurred %q", c.expected, occurred)
+		}
+	}
+}
+
+func TestMapKey_WithInterfaceKey(t *testing.T) {
+	m := map[interface{}]bool{uint8(8): true}
+	k := reflect.ValueOf(m).MapKeys()[0]
+
+	pref, err := mapKey(k)
+
+	if err != nil || pref != "8" {
+		t.Error("invalid value of map key for interface key value")
+	}
+}
+
 func TestAnvil_Notation_TimeModifier_ExpectedStringValue(t *testing.T) {
 	v := time.Now()
 	expected := []Item{
@@ -159,6 +235,53 @@ func TestAnvil_Notation_TimeModifier_ExpectedStringValue(t *testing.T) {
 	check(t, expected, r)
 }
 
+type Status int
+
+func (s Status) String() string {
+	return [...]string{"INACTIVE", "ACTIVE"}[s]
+}
+
+func TestAnvil_Notation_ScalarModifiers(t *testing.T) {
+	type Job struct {
+		Status  Status
+		Timeout time.Duration
+		Labels  map[string]string
+		Retries int
+	}
+	v := Job{Status: 1, Timeout: 90 * time.Second, Labels: map[string]string{"a": "b"}, Retries: 2}
+	expected := []Item{
+		{Key: "Job.Status", Value: "ACTIVE"},
+		{Key: "Job.Timeout", Value: "1m30s"},
+		{Key: "Job.Labels", Value: 1},
+		{Key: "Job.Retries", Value: 2},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	a.RegisterModifierFunc(Status(0), modifier.String).
+		RegisterModifierFunc(time.Duration(0), modifier.String).
+		RegisterModifierFunc(map[string]string{}, func(v reflect.Value) (interface{}, bool, error) {
+			return v.Len(), v.Len() < 1, nil
+		})
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_ModifierPanic_ExpectedError(t *testing.T) {
+	a := &Anvil{Glue: "."}
+	a.RegisterModifierFunc(Status(0), modifier.String)
+
+	_, err := a.Notation(Status(2))
+
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
 func TestAnvil_Notation_NoSkip(t *testing.T) {
 	s := "string_val"
 	f1 := []string{"one", "two", "three"}
@@ -200,7 +323,7 @@ func TestAnvil_Notation_NoSkip(t *testing.T) {
 		{Key: "MyType.PointerStr.F1[2]", Value: v.PointerStr.F1[2]},
 		{Key: "MyType.PointerStr.F2", Value: nil},
 		{Key: "MyType.Time", Value: clock.Format(time.RFC3339Nano)},
-		{Key: "MyType.Face", Value: nil},
+		{Key: "MyType.-", Value: nil},
 		{Key: "MyType.digits.Int", Value: v.digits.Int},
 		{Key: "MyType.digits.Int8", Value: v.digits.Int8},
 		{Key: "MyType.digits.Int16", Value: v.digits.Int16},
@@ -280,7 +403,6 @@ func TestAnvil_Notation_Skip(t *testing.T) {
 }
 
 func TestAnvil_Notation_Map_WithInt16Keys(t *testing.T) {
-	t.Skip()
 	type Str struct {
 		Map map[int16]string
 	}
@@ -306,7 +428,6 @@ func TestAnvil_Notation_Map_WithInt16Keys(t *testing.T) {
 }
 
 func TestAnvil_Notation_Map_WithUint8Keys(t *testing.T) {
-	t.Skip()
 	type Str struct {
 		Map map[uint8]string
 	}
@@ -332,7 +453,6 @@ func TestAnvil_Notation_Map_WithUint8Keys(t *testing.T) {
 }
 
 func TestAnvil_Notation_Map_WithFloat64Keys(t *testing.T) {
-	t.Skip()
 	type Str struct {
 		Map map[float64]string
 	}
@@ -341,8 +461,8 @@ func TestAnvil_Notation_Map_WithFloat64Keys(t *testing.T) {
 		-23456789.01: "Two",
 	}
 	expected := []Item{
-		{Key: "Str.Map[0.12345678901]", Value: "One"},
 		{Key: "Str.Map[-23456789.01]", Value: "Two"},
+		{Key: "Str.Map[0.12345678901]", Value: "One"},
 	}
 	v := Str{Map: m}
 
@@ -358,13 +478,12 @@ func TestAnvil_Notation_Map_WithFloat64Keys(t *testing.T) {
 }
 
 func TestAnvil_Notation_Map_WithBoolKeys(t *testing.T) {
-	t.Skip()
 	type Str struct {
 		MapBool map[bool]string
 	}
 	expected := []Item{
-		{Key: "Str.MapBool[true]", Value: "Uno"},
 		{Key: "Str.MapBool[false]", Value: "Dos"},
+		{Key: "Str.MapBool[true]", Value: "Uno"},
 	}
 	m := map[bool]string{
 		true:  "Uno",
@@ -383,15 +502,14 @@ func TestAnvil_Notation_Map_WithBoolKeys(t *testing.T) {
 	check(t, expected, r)
 }
 
-// in case of not implemented types
-func TestNotation_Map_WithNotImplementedKeysTypes_ExpectedEmptyKey(t *testing.T) {
-	t.Skip()
+// in case of composite keys types
+func TestNotation_Map_WithStructKeys_ExpectedCompositeKey(t *testing.T) {
 	type Str struct {
 		MapBool map[struct{ T string }]string
 	}
 	expected := []Item{
-		{Key: "Str.MapBool[]", Value: "One"},
-		{Key: "Str.MapBool[]", Value: "Two"},
+		{Key: "Str.MapBool[{Dos}]", Value: "Two"},
+		{Key: "Str.MapBool[{Uno}]", Value: "One"},
 	}
 	m := map[struct{ T string }]string{
 		struct{ T string }{T: "Uno"}: "One",
@@ -408,6 +526,20 @@ func TestNotation_Map_WithNotImplementedKeysTypes_ExpectedEmptyKey(t *testing.T)
 	check(t, expected, r)
 }
 
+func TestNotation_Map_WithPointerKeys_ExpectedError(t *testing.T) {
+	type Str struct {
+		Map map[*int]string
+	}
+	i := 1
+	v := Str{Map: map[*int]string{&i: "One"}}
+
+	_, err := Notation(v, SkipEmpty, ".")
+
+	if err == nil {
+		t.Error("error expected for a key without representation")
+	}
+}
+
 func TestNotation_Complex(t *testing.T) {
 	v := Complex{
 		Complex64:  complex64(complex(.1, .0)),
@@ -462,6 +594,81 @@ func TestNotation_Array(t *testing.T) {
 	check(t, expected, r)
 }
 
+func TestAnvil_Notation_WithRoot(t *testing.T) {
+	v := Backend{Host: "localhost", Port: 80}
+	cases := []struct {
+		anvil    *Anvil
+		expected []Item
+	}{
+		{
+			anvil:    &Anvil{Mode: SkipEmpty, Glue: ".", Root: "app"},
+			expected: []Item{{Key: "app.Host", Value: "localhost"}, {Key: "app.Port", Value: 80}},
+		},
+		{
+			anvil:    &Anvil{Mode: SkipEmpty, Glue: ".", OmitRoot: true},
+			expected: []Item{{Key: "Host", Value: "localhost"}, {Key: "Port", Value: 80}},
+		},
+		{
+			anvil:    &Anvil{Mode: SkipEmpty, Formatter: SeparatorFormatter{Separator: "__"}, OmitRoot: true},
+			expected: []Item{{Key: "Host", Value: "localhost"}, {Key: "Port", Value: 80}},
+		},
+	}
+	for _, c := range cases {
+		r, err := c.anvil.Notation(&v)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		check(t, c.expected, r)
+
+		var occurred Backend
+		if err = c.anvil.Unnotation(r, &occurred); err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if occurred != v {
+			t.Errorf("expected %#v, occurred %#v", v, occurred)
+		}
+	}
+}
+
+func TestNotation_AnonymousStruct_ExpectedKeysWithoutGlue(t *testing.T) {
+	v := struct {
+		Name string
+		Tags []string
+	}{Name: "a", Tags: []string{"b"}}
+	expected := []Item{{Key: "Name", Value: "a"}, {Key: "Tags[0]", Value: "b"}}
+
+	r, err := Notation(v, SkipEmpty, ".")
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Root_WithTypeArguments(t *testing.T) {
+	cases := map[string]string{
+		"List[int]":                    "List",
+		"Pair[string,example.com/x.T]": "Pair",
+		"Config":                       "Config",
+	}
+	for name, expected := range cases {
+		if r := (&Anvil{}).root(named{Type: reflect.TypeOf(Config{}), name: name}); r != expected {
+			t.Errorf("%s: expected %q, occurred %q", name, expected, r)
+		}
+	}
+}
+
+// named type stub to check names of generic types
+type named struct {
+	reflect.Type
+	name string
+}
+
+func (n named) Name() string { return n.name }
+
 func check(t *testing.T, expected, occurred []Item) {
 	t.Helper()
 	var (
@@ -0,0 +1,249 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"reflect"
+	"strconv"
+)
+
+// bits size of scalar kinds for parsing of map keys
+var bits = map[string]int{
+	"int": 0, "int8": 8, "int16": 16, "int32": 32, "int64": 64,
+	"uint": 0, "uint8": 8, "uint16": 16, "uint32": 32, "uint64": 64, "uintptr": 0,
+	"float32": 32, "float64": 64,
+}
+
+// publicDecoder unnotation function of annotated type
+func (g *generator) publicDecoder(name string) {
+	g.enqueueDecoder(name)
+	g.imports["fmt"] = true
+	g.imports["strings"] = true
+	g.printf("\n// Unnotation%s of items into v,\n", export(name))
+	g.printf("// generated equivalent of anvil.Anvil.Unnotation with a glue %q\n", g.glue)
+	g.printf("func Unnotation%s(items []anvil.Item, v *%s) error {\n", export(name), name)
+	g.printf("for i := range items {\n")
+	// a type name must be followed by a glue, a bracket or nothing
+	g.printf("if key := items[i].Key; !strings.HasPrefix(key, %q) ||\n", name)
+	g.printf("len(key) > %d && !strings.HasPrefix(key, %q) && !strings.HasPrefix(key, %q) {\n", len(name), name+g.glue, name+"[")
+	g.printf("return fmt.Errorf(\"anvil:key %%q does not belong to %%q\", items[i].Key, %q)\n}\n", name)
+	g.printf("segments, err := anvilDecoder.Split(items[i].Key[%d:])\n", len(name))
+	g.printf("if err != nil {\nreturn err\n}\n")
+	g.printf("if err = %s(v, segments, items[i].Value); err != nil {\n", decoder(name))
+	g.printf("return fmt.Errorf(\"anvil:%%s: %%v\", items[i].Key, err)\n}\n}\n")
+	g.printf("return nil\n}\n")
+}
+
+// decoderHelper of a structure type, sets value by key segments
+func (g *generator) decoderHelper(name string) error {
+	st := g.types[name].Type.(*ast.StructType)
+	g.imports["fmt"] = true
+	g.printf("\nfunc %s(v *%s, segments []anvil.Segment, value interface{}) error {\n", decoder(name), name)
+	g.printf("if len(segments) < 1 {\nreturn anvilDecoder.Assign(v, segments, value)\n}\n")
+	g.printf("switch segments[0].Name {\n")
+	var inline bool
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			t, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(t)
+		}
+		names := make([]string, 0, len(f.Names))
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) < 1 {
+			names = append(names, embedded(f.Type))
+		}
+		for _, n := range names {
+			field := g.names.FieldTag(reflect.StructField{Name: n, Tag: tag})
+			// unexported fields are not set by unnotation
+			if !ast.IsExported(n) || field.Skip {
+				continue
+			}
+			// fields of inlined values are looked up by reflection
+			if field.Inline {
+				inline = true
+				continue
+			}
+			g.printf("case %q:\n", field.Name)
+			if err := g.decode(f.Type, "v."+n, "segments[1:]", 0, name+"."+n); err != nil {
+				return err
+			}
+		}
+	}
+	g.printf("default:\n")
+	if inline {
+		g.printf("return anvilDecoder.Assign(v, segments, value)\n}\n")
+	} else {
+		g.printf("return fmt.Errorf(\"field %%q not found in %s\", segments[0].Name)\n}\n", name)
+	}
+	g.printf("return nil\n}\n")
+	return nil
+}
+
+// decode code setting value to x expression with type t by segments expression,
+// falls through on success and returns an error otherwise
+func (g *generator) decode(t ast.Expr, x, segments string, depth int, path string) error {
+	if !g.local(t) {
+		// types of other packages, interfaces and anonymous structures use reflection
+		g.printf("if err := anvilDecoder.Assign(%s, %s, value); err != nil {\nreturn err\n}\n", addr(x), segments)
+		return nil
+	}
+	g.imports["fmt"] = true
+	switch t := t.(type) {
+	case *ast.ParenExpr:
+		return g.decode(t.X, x, segments, depth, path)
+	case *ast.Ident:
+		if spec, ok := g.types[t.Name]; ok {
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				g.enqueueDecoder(t.Name)
+				g.printf("if err := %s(%s, %s, value); err != nil {\nreturn err\n}\n", decoder(t.Name), addr(x), segments)
+				return nil
+			}
+			if s, ok := g.scalar(spec.Type); ok {
+				g.leaf(s.conv, t.Name, x, segments)
+				return nil
+			}
+			return g.decode(spec.Type, x, segments, depth, path)
+		}
+		if s, ok := scalars[t.Name]; ok {
+			g.leaf(s.conv, "", x, segments)
+			return nil
+		}
+	case *ast.StarExpr:
+		g.printf("if len(%s) < 1 && value == nil {\n%s = nil\n} else {\n", segments, x)
+		g.printf("if %s == nil {\n%s = new(%s)\n}\n", x, x, typeString(t.X))
+		if err := g.decode(t.X, "(*"+x+")", segments, depth, path); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	case *ast.ArrayType:
+		g.imports["strconv"] = true
+		s := fmt.Sprintf("s%d", depth)
+		g.printf("if %s := %s; len(%s) < 1 {\n", s, segments, s)
+		g.printf("if err := anvilDecoder.Assign(%s, %s, value); err != nil {\nreturn err\n}\n", addr(x), s)
+		g.printf("} else {\n")
+		g.printf("i%d, err := strconv.Atoi(%s[0].Name)\n", depth, s)
+		if t.Len == nil {
+			g.printf("if err != nil || i%d < 0 || i%d > anvil.DefaultMaxIndex {\n", depth, depth)
+		} else {
+			g.printf("if err != nil || i%d < 0 || i%d >= len(%s) {\n", depth, depth, x)
+		}
+		g.printf("return fmt.Errorf(\"invalid index %%q of %s\", %s[0].Name)\n}\n", path, s)
+		if t.Len == nil {
+			g.printf("if i%d >= len(%s) {\n", depth, x)
+			g.printf("%s = append(%s, make(%s, i%d+1-len(%s))...)\n}\n", x, x, typeString(t), depth, x)
+		}
+		if err := g.decode(t.Elt, fmt.Sprintf("%s[i%d]", x, depth), s+"[1:]", depth+1, path+"[]"); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	case *ast.MapType:
+		k, ok := g.scalar(t.Key)
+		if !ok || k.conv == "complex64" || k.conv == "complex128" {
+			break
+		}
+		s := fmt.Sprintf("s%d", depth)
+		g.printf("if %s := %s; len(%s) < 1 {\n", s, segments, s)
+		g.printf("if err := anvilDecoder.Assign(%s, %s, value); err != nil {\nreturn err\n}\n", addr(x), s)
+		g.printf("} else {\n")
+		key := typeString(t.Key)
+		switch k.conv {
+		case "string":
+			if key == "string" {
+				g.printf("m%d := %s[0].Name\n", depth, s)
+			} else {
+				g.printf("m%d := %s(%s[0].Name)\n", depth, key, s)
+			}
+		case "bool":
+			g.imports["strconv"] = true
+			g.printf("b%d, err := strconv.ParseBool(%s[0].Name)\n", depth, s)
+			g.printf("if err != nil {\nreturn err\n}\nm%d := %s(b%d)\n", depth, key, depth)
+		default:
+			g.imports["strconv"] = true
+			parse := fmt.Sprintf("strconv.ParseInt(%s[0].Name, 10, %d)", s, bits[k.conv])
+			if k.conv[0] == 'u' {
+				parse = fmt.Sprintf("strconv.ParseUint(%s[0].Name, 10, %d)", s, bits[k.conv])
+			} else if k.conv[0] == 'f' {
+				parse = fmt.Sprintf("strconv.ParseFloat(%s[0].Name, %d)", s, bits[k.conv])
+			}
+			g.printf("b%d, err := %s\n", depth, parse)
+			g.printf("if err != nil {\nreturn err\n}\nm%d := %s(b%d)\n", depth, key, depth)
+		}
+		g.printf("if %s == nil {\n%s = make(%s)\n}\n", x, x, typeString(t))
+		// map elements are not addressable, modify a copy
+		g.printf("e%d := %s[m%d]\n", depth, x, depth)
+		if err := g.decode(t.Value, fmt.Sprintf("e%d", depth), s+"[1:]", depth+1, path+"[]"); err != nil {
+			return err
+		}
+		g.printf("%s[m%d] = e%d\n}\n", x, depth, depth)
+		return nil
+	}
+	return fmt.Errorf("%s: type %s is not supported", path, typeString(t))
+}
+
+// leaf scalar value assignment, exact kind values are assigned without reflection
+func (g *generator) leaf(kind, named, x, segments string) {
+	g.printf("if len(%s) > 0 {\n", segments)
+	g.printf("return fmt.Errorf(\"unexpected segment %%q\", %s[0].Name)\n}\n", segments)
+	value := "c"
+	if len(named) > 0 {
+		value = named + "(c)"
+	}
+	g.printf("if c, ok := value.(%s); ok {\n%s = %s\n", kind, x, value)
+	g.printf("} else if err := anvilDecoder.Assign(%s, nil, value); err != nil {\nreturn err\n}\n", addr(x))
+}
+
+// local type expression refers to builtin and package types only
+func (g *generator) local(t ast.Expr) bool {
+	switch t := t.(type) {
+	case *ast.ParenExpr:
+		return g.local(t.X)
+	case *ast.Ident:
+		if spec, ok := g.types[t.Name]; ok {
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				return true
+			}
+			return g.local(spec.Type)
+		}
+		_, ok := scalars[t.Name]
+		return ok
+	case *ast.StarExpr:
+		return g.local(t.X)
+	case *ast.ArrayType:
+		return g.local(t.Elt)
+	case *ast.MapType:
+		return g.local(t.Key) && g.local(t.Value)
+	}
+	return false
+}
+
+// enqueueDecoder structure type to generate a decoder helper
+func (g *generator) enqueueDecoder(name string) {
+	if g.decoded[name] {
+		return
+	}
+	g.decoded[name] = true
+	g.decoders = append(g.decoders, name)
+}
+
+// decoderVar of keys with a glue used by generation
+func (g *generator) decoderVar() {
+	g.printf("\n// anvilDecoder of notation keys\n")
+	g.printf("var anvilDecoder = &anvil.Anvil{Glue: %q%s}\n", g.glue, g.tags())
+}
+
+// decoder helper function name of a structure type
+func decoder(name string) string {
+	return "unnotation" + export(name)
+}
@@ -0,0 +1,533 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iveronanomi/anvil"
+)
+
+// directive of a type comment to generate functions for
+const directive = "anvil:generate"
+
+// scalar kind representation in generated code
+type scalar struct {
+	// conversion to a kind type, same as a type of value made by reflection
+	conv string
+	// condition of not empty value
+	full string
+}
+
+// scalars builtin types
+var scalars = map[string]scalar{
+	"int":        {conv: "int", full: "%s != 0"},
+	"int8":       {conv: "int8", full: "%s != 0"},
+	"int16":      {conv: "int16", full: "%s != 0"},
+	"int32":      {conv: "int32", full: "%s != 0"},
+	"rune":       {conv: "int32", full: "%s != 0"},
+	"int64":      {conv: "int64", full: "%s != 0"},
+	"uint":       {conv: "uint", full: "%s != 0"},
+	"uint8":      {conv: "uint8", full: "%s != 0"},
+	"byte":       {conv: "uint8", full: "%s != 0"},
+	"uint16":     {conv: "uint16", full: "%s != 0"},
+	"uint32":     {conv: "uint32", full: "%s != 0"},
+	"uint64":     {conv: "uint64", full: "%s != 0"},
+	"uintptr":    {conv: "uintptr", full: "%s != 0"},
+	"float32":    {conv: "float32", full: "%s != 0"},
+	"float64":    {conv: "float64", full: "%s != 0"},
+	"complex64":  {conv: "complex64", full: "%s != 0"},
+	"complex128": {conv: "complex128", full: "%s != 0"},
+	"bool":       {conv: "bool", full: "%s"},
+	"string":     {conv: "string", full: "len(%s) > 0"},
+}
+
+// generator of notation functions for a package
+type generator struct {
+	// package name
+	pkg string
+	// type specifications of a package
+	types map[string]*ast.TypeSpec
+	// annotated types in order of declaration
+	annotated []string
+	// types with `IsZero() bool` method defining emptiness of values
+	zeroers map[string]bool
+	// glue of keys for unnotation functions
+	glue string
+	// structure types to generate helpers for
+	queue  []string
+	queued map[string]bool
+	// structure types to generate decoder helpers for
+	decoders []string
+	decoded  map[string]bool
+	// imports used by generated code
+	imports map[string]bool
+	// reflection notation used for types out of a package
+	reflection bool
+	// naming of fields same as in a notation
+	names anvil.Anvil
+	buf   bytes.Buffer
+}
+
+// Generate source code of notation and unnotation functions for a package in dir,
+// keys of unnotation are split by a glue, field names are taken from tags in order,
+// output file is not parsed to be regenerated
+func Generate(dir, output, glue string, tags []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go") && fi.Name() != output
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+	g := &generator{
+		glue:    glue,
+		types:   make(map[string]*ast.TypeSpec),
+		zeroers: make(map[string]bool),
+		queued:  make(map[string]bool),
+		decoded: make(map[string]bool),
+		imports: map[string]bool{"github.com/iveronanomi/anvil": true},
+		names:   anvil.Anvil{Tags: tags},
+	}
+	for name, pkg := range pkgs {
+		g.pkg = name
+		g.collect(pkg)
+	}
+	if len(g.annotated) < 1 {
+		return nil, fmt.Errorf("no types annotated with %q in %s", directive, dir)
+	}
+	for _, name := range g.annotated {
+		if _, ok := g.types[name].Type.(*ast.StructType); !ok {
+			return nil, fmt.Errorf("%s: only structure types could be generated", name)
+		}
+		g.public(name)
+		g.publicDecoder(name)
+	}
+	for i := 0; i < len(g.queue); i++ {
+		if err := g.helper(g.queue[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < len(g.decoders); i++ {
+		if err := g.decoderHelper(g.decoders[i]); err != nil {
+			return nil, err
+		}
+	}
+	if g.reflection {
+		g.reflectionHelper()
+	}
+	g.decoderVar()
+	return format.Source(append(g.header(), g.buf.Bytes()...))
+}
+
+// collect type specifications and IsZero methods of a package in order of files and declarations
+func (g *generator) collect(pkg *ast.Package) {
+	files := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+	for _, name := range files {
+		for _, decl := range pkg.Files[name].Decls {
+			if f, ok := decl.(*ast.FuncDecl); ok {
+				if name, ok := zeroer(f); ok {
+					g.zeroers[name] = true
+				}
+				continue
+			}
+			d, ok := decl.(*ast.GenDecl)
+			if !ok || d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				t := spec.(*ast.TypeSpec)
+				g.types[t.Name.Name] = t
+				if annotated(t.Doc) || (!d.Lparen.IsValid() && annotated(d.Doc)) {
+					g.annotated = append(g.annotated, t.Name.Name)
+				}
+			}
+		}
+	}
+}
+
+// zeroer method `IsZero() bool` of a type, a receiver type name is returned
+func zeroer(f *ast.FuncDecl) (string, bool) {
+	if f.Recv == nil || len(f.Recv.List) != 1 || f.Name.Name != "IsZero" || f.Type.Params.NumFields() > 0 {
+		return "", false
+	}
+	if f.Type.Results.NumFields() != 1 {
+		return "", false
+	}
+	if r, ok := f.Type.Results.List[0].Type.(*ast.Ident); !ok || r.Name != "bool" {
+		return "", false
+	}
+	name := embedded(f.Recv.List[0].Type)
+	return name, len(name) > 0
+}
+
+// annotated comment with a generation directive
+func annotated(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(strings.TrimPrefix(c.Text, "//")) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// header of generated file
+func (g *generator) header() []byte {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by anvil-gen. DO NOT EDIT.\n\n")
+	b.WriteString("package " + g.pkg + "\n\nimport (\n")
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		if strings.Contains(path, ".") {
+			continue
+		}
+		b.WriteString(strconv.Quote(path) + "\n")
+	}
+	b.WriteString("\n")
+	for _, path := range imports {
+		if strings.Contains(path, ".") {
+			b.WriteString(strconv.Quote(path) + "\n")
+		}
+	}
+	b.WriteString(")\n")
+	return b.Bytes()
+}
+
+// public notation function of annotated type
+func (g *generator) public(name string) {
+	g.enqueue(name)
+	g.printf("\n// Notation%s of %s as a list of []anvil.Item,\n", export(name), name)
+	g.printf("// generated equivalent of anvil.Notation without modifiers\n")
+	g.printf("func Notation%s(v *%s, glue string, mode anvil.Mode) ([]anvil.Item, error) {\n", export(name), name)
+	g.printf("if v == nil {\nreturn nil, nil\n}\n")
+	g.printf("return %s(nil, %q, v, glue, mode)\n}\n", helper(name), name)
+}
+
+// helper notation function of a structure type
+func (g *generator) helper(name string) error {
+	st := g.types[name].Type.(*ast.StructType)
+	g.printf("\nfunc %s(items []anvil.Item, key string, v *%s, glue string, mode anvil.Mode) ([]anvil.Item, error) {\n", helper(name), name)
+	g.printf("var err error\nn := len(items)\n")
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			t, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(t)
+		}
+		names := make([]string, 0, len(f.Names))
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(names) < 1 {
+			names = append(names, embedded(f.Type))
+		}
+		for _, n := range names {
+			field := g.names.FieldTag(reflect.StructField{Name: n, Tag: tag})
+			if n == "_" || field.Skip {
+				continue
+			}
+			key := "key + glue + " + strconv.Quote(field.Name)
+			if field.Inline {
+				key = "key"
+			}
+			if field.OmitEmpty {
+				// empty values of a field and its nested values are skipped
+				g.printf("{\nmode := anvil.SkipEmpty\n")
+			}
+			if err := g.value(f.Type, "v."+n, key, 0, &field, name+"."+n); err != nil {
+				return err
+			}
+			if field.OmitEmpty {
+				g.printf("}\n")
+			}
+		}
+	}
+	g.printf("if len(items) == n && mode != anvil.SkipEmpty {\n")
+	g.printf("items = append(items, anvil.Item{Key: key})\n}\n")
+	g.printf("return items, err\n}\n")
+	return nil
+}
+
+// value code appending items of x expression with type t by a key expression,
+// field is a tag of a structure field, nil for elements, nil pointers are skipped
+func (g *generator) value(t ast.Expr, x, key string, depth int, field *anvil.Tag, path string) error {
+	switch t := t.(type) {
+	case *ast.ParenExpr:
+		return g.value(t.X, x, key, depth, field, path)
+	case *ast.Ident:
+		if spec, ok := g.types[t.Name]; ok {
+			if g.zeroers[t.Name] {
+				return g.zeroValue(t, spec, x, key, depth, field, path)
+			}
+			if _, ok := spec.Type.(*ast.StructType); ok {
+				g.enqueue(t.Name)
+				g.printf("if items, err = %s(items, %s, %s, glue, mode); err != nil {\n", helper(t.Name), key, addr(x))
+				g.printf("return nil, err\n}\n")
+				return nil
+			}
+			if s, ok := g.scalar(spec.Type); ok {
+				// named scalar types are converted to a kind type
+				g.leafValue(key, s.conv+"("+x+")", fmt.Sprintf(s.full, x), s, field)
+				return nil
+			}
+			return g.value(spec.Type, x, key, depth, field, path)
+		}
+		if s, ok := scalars[t.Name]; ok {
+			g.leafValue(key, x, fmt.Sprintf(s.full, x), s, field)
+			return nil
+		}
+		if t.Name == "error" || t.Name == "any" {
+			return g.value(&ast.InterfaceType{}, x, key, depth, field, path)
+		}
+	case *ast.StarExpr:
+		g.printf("if %s != nil {\n", x)
+		if err := g.value(t.X, "(*"+x+")", key, depth, field, path); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	case *ast.ArrayType:
+		g.imports["strconv"] = true
+		g.printf("{\nk%d := %s\nn%d := len(items)\n", depth, key, depth)
+		g.printf("for i%d := range %s {\n", depth, x)
+		index := fmt.Sprintf("k%d + \"[\" + strconv.Itoa(i%d) + \"]\"", depth, depth)
+		if err := g.value(t.Elt, fmt.Sprintf("%s[i%d]", x, depth), index, depth+1, nil, path+"[]"); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		g.empty(depth)
+		return nil
+	case *ast.MapType:
+		format, ok := g.mapKey(t.Key, fmt.Sprintf("m%d", depth))
+		if !ok {
+			g.fallback()
+			g.printf("if items, err = anvilNotation(items, %s, %s, glue, mode); err != nil {\n", key, x)
+			g.printf("return nil, err\n}\n")
+			return nil
+		}
+		g.printf("{\nk%d := %s\nn%d := len(items)\n", depth, key, depth)
+		g.sortedKeys(t.Key, x, depth)
+		g.printf("for _, m%d := range s%d {\ne%d := %s[m%d]\n", depth, depth, depth, x, depth)
+		index := fmt.Sprintf("k%d + \"[\" + %s + \"]\"", depth, format)
+		if err := g.value(t.Value, fmt.Sprintf("e%d", depth), index, depth+1, nil, path+"[]"); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		g.empty(depth)
+		return nil
+	case *ast.InterfaceType:
+		g.fallback()
+		g.printf("if %s == nil {\n", x)
+		g.printf("if mode != anvil.SkipEmpty {\nitems = append(items, anvil.Item{Key: %s})\n}\n", key)
+		g.printf("} else if items, err = anvilNotation(items, %s, %s, glue, mode); err != nil {\n", key, x)
+		g.printf("return nil, err\n}\n")
+		return nil
+	case *ast.SelectorExpr, *ast.StructType:
+		if s, ok := t.(*ast.SelectorExpr); ok && s.Sel.Name == "Pointer" {
+			if p, ok := s.X.(*ast.Ident); ok && p.Name == "unsafe" {
+				break
+			}
+		}
+		if field != nil && field.String {
+			return fmt.Errorf("%s: string option of type %s is not supported", path, typeString(t))
+		}
+		// types of other packages and anonymous structures use reflection
+		g.fallback()
+		g.printf("if items, err = anvilNotation(items, %s, %s, glue, mode); err != nil {\n", key, x)
+		g.printf("return nil, err\n}\n")
+		return nil
+	}
+	return fmt.Errorf("%s: type %s is not supported", path, typeString(t))
+}
+
+// zeroValue code of x expression with a type having IsZero method, same as reflection does:
+// a scalar value is empty by IsZero only, other values are skipped entirely if IsZero
+func (g *generator) zeroValue(t *ast.Ident, spec *ast.TypeSpec, x, key string, depth int, field *anvil.Tag, path string) error {
+	if s, ok := g.scalar(spec.Type); ok {
+		g.leafValue(key, s.conv+"("+x+")", "!"+x+".IsZero()", s, field)
+		return nil
+	}
+	g.printf("if mode != anvil.SkipEmpty || !%s.IsZero() {\n", x)
+	delete(g.zeroers, t.Name)
+	err := g.value(t, x, key, depth, field, path)
+	g.zeroers[t.Name] = true
+	g.printf("}\n")
+	return err
+}
+
+// leafValue item of a scalar value expression, notEmpty is a condition of not empty value,
+// value of a field with a string option is represented as a string
+func (g *generator) leafValue(key, value, notEmpty string, s scalar, field *anvil.Tag) {
+	if field != nil && field.String && s.conv != "string" {
+		g.imports["fmt"] = true
+		value = "fmt.Sprint(" + value + ")"
+	}
+	g.printf("if mode != anvil.SkipEmpty || %s {\n", notEmpty)
+	g.printf("items = append(items, anvil.Item{Key: %s, Value: %s})\n}\n", key, value)
+}
+
+// sortedKeys of a map x declared as s<depth>, in natural ordering of keys same as reflection does
+func (g *generator) sortedKeys(t ast.Expr, x string, depth int) {
+	g.imports["sort"] = true
+	less := "s%[1]d[i] < s%[1]d[j]"
+	switch s, _ := g.scalar(t); s.conv {
+	case "bool":
+		less = "!bool(s%[1]d[i]) && bool(s%[1]d[j])"
+	case "float32", "float64":
+		// NaN keys first
+		less = "s%[1]d[i] < s%[1]d[j] || s%[1]d[i] != s%[1]d[i] && s%[1]d[j] == s%[1]d[j]"
+	}
+	g.printf("s%d := make([]%s, 0, len(%s))\n", depth, typeString(t), x)
+	g.printf("for m%d := range %s {\ns%d = append(s%d, m%d)\n}\n", depth, x, depth, depth, depth)
+	g.printf("sort.Slice(s%[1]d, func(i, j int) bool {\nreturn "+less+"\n})\n", depth)
+}
+
+// empty container value, when nothing appended
+func (g *generator) empty(depth int) {
+	g.printf("if len(items) == n%d && mode != anvil.SkipEmpty {\n", depth)
+	g.printf("items = append(items, anvil.Item{Key: k%d})\n}\n}\n", depth)
+}
+
+// mapKey formatting expression of a key variable, same as a map prefix made by reflection
+func (g *generator) mapKey(t ast.Expr, k string) (string, bool) {
+	s, ok := g.scalar(t)
+	if !ok {
+		return "", false
+	}
+	switch s.conv {
+	case "string":
+		if id := t.(*ast.Ident); id.Name == "string" {
+			return k, true
+		}
+		return "string(" + k + ")", true
+	case "bool":
+		g.imports["strconv"] = true
+		return "strconv.FormatBool(bool(" + k + "))", true
+	case "float32", "float64":
+		g.imports["strconv"] = true
+		return fmt.Sprintf("strconv.FormatFloat(float64(%s), 'f', -1, %s)", k, s.conv[5:]), true
+	case "complex64", "complex128":
+		return "", false
+	}
+	g.imports["strconv"] = true
+	if strings.HasPrefix(s.conv, "uint") {
+		return "strconv.FormatUint(uint64(" + k + "), 10)", true
+	}
+	return "strconv.FormatInt(int64(" + k + "), 10)", true
+}
+
+// scalar representation of a builtin or named scalar type
+func (g *generator) scalar(t ast.Expr) (scalar, bool) {
+	id, ok := t.(*ast.Ident)
+	if !ok {
+		return scalar{}, false
+	}
+	if spec, ok := g.types[id.Name]; ok {
+		return g.scalar(spec.Type)
+	}
+	s, ok := scalars[id.Name]
+	return s, ok
+}
+
+// fallback to reflection notation for types out of a package
+func (g *generator) fallback() {
+	g.reflection = true
+}
+
+// reflectionHelper function of a generated file
+func (g *generator) reflectionHelper() {
+	g.printf("\n// anvilNotation of a value by reflection\n")
+	g.printf("func anvilNotation(items []anvil.Item, key string, v interface{}, glue string, mode anvil.Mode) ([]anvil.Item, error) {\n")
+	g.printf("n, err := (&anvil.Anvil{Mode: mode, Glue: glue%s}).NotationWithKey(key, v)\n", g.tags())
+	g.printf("return append(items, n...), err\n}\n")
+}
+
+// tags field of anvil.Anvil literal, empty for default tags
+func (g *generator) tags() string {
+	if len(g.names.Tags) < 1 {
+		return ""
+	}
+	return fmt.Sprintf(", Tags: %#v", g.names.Tags)
+}
+
+// enqueue structure type to generate a helper
+func (g *generator) enqueue(name string) {
+	if g.queued[name] {
+		return
+	}
+	g.queued[name] = true
+	g.queue = append(g.queue, name)
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// addr expression of x
+func addr(x string) string {
+	if strings.HasPrefix(x, "(*") && strings.HasSuffix(x, ")") {
+		return x[2 : len(x)-1]
+	}
+	return "&" + x
+}
+
+// helper function name of a structure type
+func helper(name string) string {
+	return "notation" + export(name)
+}
+
+// export name with upper first letter
+func export(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// embedded field name by a type
+func embedded(t ast.Expr) string {
+	switch t := t.(type) {
+	case *ast.StarExpr:
+		return embedded(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// typeString representation of type expression for errors
+func typeString(t ast.Expr) string {
+	var b bytes.Buffer
+	if err := format.Node(&b, token.NewFileSet(), t); err != nil {
+		return fmt.Sprintf("%T", t)
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate_UpToDate(t *testing.T) {
+	dir := filepath.Join("internal", "sample")
+	expected, err := ioutil.ReadFile(filepath.Join(dir, "anvil_gen.go"))
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	occurred, err := Generate(dir, "anvil_gen.go", ".", nil)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !bytes.Equal(expected, occurred) {
+		t.Error("generated code of sample package is out of date, run `go generate ./...`")
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	cases := map[string]string{
+		"not annotated": "type T struct{ A int }",
+		"not a struct":  "//anvil:generate\ntype T []int",
+		"channel":       "//anvil:generate\ntype T struct{ C chan int }",
+		"function":      "//anvil:generate\ntype T struct{ F func() }",
+		"unsafe":        "//anvil:generate\ntype T struct{ P unsafe.Pointer }",
+	}
+	for name, src := range cases {
+		dir, err := ioutil.TempDir("", "anvil-gen")
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		err = ioutil.WriteFile(filepath.Join(dir, "t.go"), []byte("package t\n\n"+src+"\n"), 0644)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		if _, err = Generate(dir, "anvil_gen.go", ".", nil); err == nil {
+			t.Errorf("%s: error expected", name)
+		}
+		os.RemoveAll(dir)
+	}
+}
@@ -0,0 +1,643 @@
+// Code generated by anvil-gen. DO NOT EDIT.
+
+package sample
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iveronanomi/anvil"
+)
+
+// NotationUser of User as a list of []anvil.Item,
+// generated equivalent of anvil.Notation without modifiers
+func NotationUser(v *User, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return notationUser(nil, "User", v, glue, mode)
+}
+
+// UnnotationUser of items into v,
+// generated equivalent of anvil.Anvil.Unnotation with a glue "."
+func UnnotationUser(items []anvil.Item, v *User) error {
+	for i := range items {
+		if key := items[i].Key; !strings.HasPrefix(key, "User") ||
+			len(key) > 4 && !strings.HasPrefix(key, "User.") && !strings.HasPrefix(key, "User[") {
+			return fmt.Errorf("anvil:key %q does not belong to %q", items[i].Key, "User")
+		}
+		segments, err := anvilDecoder.Split(items[i].Key[4:])
+		if err != nil {
+			return err
+		}
+		if err = unnotationUser(v, segments, items[i].Value); err != nil {
+			return fmt.Errorf("anvil:%s: %v", items[i].Key, err)
+		}
+	}
+	return nil
+}
+
+// NotationAddress of Address as a list of []anvil.Item,
+// generated equivalent of anvil.Notation without modifiers
+func NotationAddress(v *Address, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return notationAddress(nil, "Address", v, glue, mode)
+}
+
+// UnnotationAddress of items into v,
+// generated equivalent of anvil.Anvil.Unnotation with a glue "."
+func UnnotationAddress(items []anvil.Item, v *Address) error {
+	for i := range items {
+		if key := items[i].Key; !strings.HasPrefix(key, "Address") ||
+			len(key) > 7 && !strings.HasPrefix(key, "Address.") && !strings.HasPrefix(key, "Address[") {
+			return fmt.Errorf("anvil:key %q does not belong to %q", items[i].Key, "Address")
+		}
+		segments, err := anvilDecoder.Split(items[i].Key[7:])
+		if err != nil {
+			return err
+		}
+		if err = unnotationAddress(v, segments, items[i].Value); err != nil {
+			return fmt.Errorf("anvil:%s: %v", items[i].Key, err)
+		}
+	}
+	return nil
+}
+
+func notationUser(items []anvil.Item, key string, v *User, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	var err error
+	n := len(items)
+	if items, err = notationAudit(items, key+glue+"Audit", &v.Audit, glue, mode); err != nil {
+		return nil, err
+	}
+	if mode != anvil.SkipEmpty || len(v.Name) > 0 {
+		items = append(items, anvil.Item{Key: key + glue + "name", Value: v.Name})
+	}
+	if mode != anvil.SkipEmpty || v.Age != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "Age", Value: v.Age})
+	}
+	{
+		mode := anvil.SkipEmpty
+		if mode != anvil.SkipEmpty || v.Level != 0 {
+			items = append(items, anvil.Item{Key: key + glue + "level", Value: uint8(v.Level)})
+		}
+	}
+	if mode != anvil.SkipEmpty || v.Score != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "Score", Value: v.Score})
+	}
+	if mode != anvil.SkipEmpty || v.Active {
+		items = append(items, anvil.Item{Key: key + glue + "Active", Value: v.Active})
+	}
+	{
+		k0 := key + glue + "Tags"
+		n0 := len(items)
+		for i0 := range v.Tags {
+			if mode != anvil.SkipEmpty || len(v.Tags[i0]) > 0 {
+				items = append(items, anvil.Item{Key: k0 + "[" + strconv.Itoa(i0) + "]", Value: v.Tags[i0]})
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	{
+		k0 := key + glue + "Codes"
+		n0 := len(items)
+		for i0 := range v.Codes {
+			if mode != anvil.SkipEmpty || v.Codes[i0] != 0 {
+				items = append(items, anvil.Item{Key: k0 + "[" + strconv.Itoa(i0) + "]", Value: v.Codes[i0]})
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	if v.Address != nil {
+		if items, err = notationAddress(items, key+glue+"Address", v.Address, glue, mode); err != nil {
+			return nil, err
+		}
+	}
+	{
+		k0 := key + glue + "Addresses"
+		n0 := len(items)
+		for i0 := range v.Addresses {
+			if items, err = notationAddress(items, k0+"["+strconv.Itoa(i0)+"]", &v.Addresses[i0], glue, mode); err != nil {
+				return nil, err
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	{
+		k0 := key + glue + "Previous"
+		n0 := len(items)
+		for i0 := range v.Previous {
+			if v.Previous[i0] != nil {
+				if items, err = notationAddress(items, k0+"["+strconv.Itoa(i0)+"]", v.Previous[i0], glue, mode); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	{
+		k0 := key + glue + "Phones"
+		n0 := len(items)
+		s0 := make([]string, 0, len(v.Phones))
+		for m0 := range v.Phones {
+			s0 = append(s0, m0)
+		}
+		sort.Slice(s0, func(i, j int) bool {
+			return s0[i] < s0[j]
+		})
+		for _, m0 := range s0 {
+			e0 := v.Phones[m0]
+			if mode != anvil.SkipEmpty || len(e0) > 0 {
+				items = append(items, anvil.Item{Key: k0 + "[" + m0 + "]", Value: e0})
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	{
+		k0 := key + glue + "Contacts"
+		n0 := len(items)
+		s0 := make([]int, 0, len(v.Contacts))
+		for m0 := range v.Contacts {
+			s0 = append(s0, m0)
+		}
+		sort.Slice(s0, func(i, j int) bool {
+			return s0[i] < s0[j]
+		})
+		for _, m0 := range s0 {
+			e0 := v.Contacts[m0]
+			if e0 != nil {
+				if items, err = notationAddress(items, k0+"["+strconv.FormatInt(int64(m0), 10)+"]", e0, glue, mode); err != nil {
+					return nil, err
+				}
+			}
+		}
+		if len(items) == n0 && mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: k0})
+		}
+	}
+	if v.Meta == nil {
+		if mode != anvil.SkipEmpty {
+			items = append(items, anvil.Item{Key: key + glue + "Meta"})
+		}
+	} else if items, err = anvilNotation(items, key+glue+"Meta", v.Meta, glue, mode); err != nil {
+		return nil, err
+	}
+	if items, err = anvilNotation(items, key+glue+"Created", v.Created, glue, mode); err != nil {
+		return nil, err
+	}
+	if items, err = notationLimits(items, key, &v.Limits, glue, mode); err != nil {
+		return nil, err
+	}
+	if mode != anvil.SkipEmpty || v.Rank != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "rank", Value: fmt.Sprint(v.Rank)})
+	}
+	if mode != anvil.SkipEmpty || v.Handle != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "Handle", Value: v.Handle})
+	}
+	if v.Backup != nil {
+		if (*v.Backup) != nil {
+			if items, err = notationAddress(items, key+glue+"Backup", (*v.Backup), glue, mode); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if mode != anvil.SkipEmpty || !v.Share.IsZero() {
+		items = append(items, anvil.Item{Key: key + glue + "Share", Value: int(v.Share)})
+	}
+	if mode != anvil.SkipEmpty || !v.Trial.IsZero() {
+		if items, err = notationPeriod(items, key+glue+"Trial", &v.Trial, glue, mode); err != nil {
+			return nil, err
+		}
+	}
+	if mode != anvil.SkipEmpty || len(v.password) > 0 {
+		items = append(items, anvil.Item{Key: key + glue + "password", Value: v.password})
+	}
+	if len(items) == n && mode != anvil.SkipEmpty {
+		items = append(items, anvil.Item{Key: key})
+	}
+	return items, err
+}
+
+func notationAddress(items []anvil.Item, key string, v *Address, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	var err error
+	n := len(items)
+	if mode != anvil.SkipEmpty || len(v.City) > 0 {
+		items = append(items, anvil.Item{Key: key + glue + "city", Value: v.City})
+	}
+	if mode != anvil.SkipEmpty || len(v.Street) > 0 {
+		items = append(items, anvil.Item{Key: key + glue + "-", Value: v.Street})
+	}
+	if mode != anvil.SkipEmpty || v.Zip != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "Zip", Value: v.Zip})
+	}
+	if len(items) == n && mode != anvil.SkipEmpty {
+		items = append(items, anvil.Item{Key: key})
+	}
+	return items, err
+}
+
+func notationAudit(items []anvil.Item, key string, v *Audit, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	var err error
+	n := len(items)
+	if mode != anvil.SkipEmpty || len(v.Author) > 0 {
+		items = append(items, anvil.Item{Key: key + glue + "Author", Value: v.Author})
+	}
+	if len(items) == n && mode != anvil.SkipEmpty {
+		items = append(items, anvil.Item{Key: key})
+	}
+	return items, err
+}
+
+func notationLimits(items []anvil.Item, key string, v *Limits, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	var err error
+	n := len(items)
+	if mode != anvil.SkipEmpty || v.MaxItems != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "max_items", Value: v.MaxItems})
+	}
+	if len(items) == n && mode != anvil.SkipEmpty {
+		items = append(items, anvil.Item{Key: key})
+	}
+	return items, err
+}
+
+func notationPeriod(items []anvil.Item, key string, v *Period, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	var err error
+	n := len(items)
+	if mode != anvil.SkipEmpty || v.From != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "From", Value: v.From})
+	}
+	if mode != anvil.SkipEmpty || v.To != 0 {
+		items = append(items, anvil.Item{Key: key + glue + "To", Value: v.To})
+	}
+	if len(items) == n && mode != anvil.SkipEmpty {
+		items = append(items, anvil.Item{Key: key})
+	}
+	return items, err
+}
+
+func unnotationUser(v *User, segments []anvil.Segment, value interface{}) error {
+	if len(segments) < 1 {
+		return anvilDecoder.Assign(v, segments, value)
+	}
+	switch segments[0].Name {
+	case "Audit":
+		if err := unnotationAudit(&v.Audit, segments[1:], value); err != nil {
+			return err
+		}
+	case "name":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(string); ok {
+			v.Name = c
+		} else if err := anvilDecoder.Assign(&v.Name, nil, value); err != nil {
+			return err
+		}
+	case "Age":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(int); ok {
+			v.Age = c
+		} else if err := anvilDecoder.Assign(&v.Age, nil, value); err != nil {
+			return err
+		}
+	case "level":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(uint8); ok {
+			v.Level = Level(c)
+		} else if err := anvilDecoder.Assign(&v.Level, nil, value); err != nil {
+			return err
+		}
+	case "Score":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(float32); ok {
+			v.Score = c
+		} else if err := anvilDecoder.Assign(&v.Score, nil, value); err != nil {
+			return err
+		}
+	case "Active":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(bool); ok {
+			v.Active = c
+		} else if err := anvilDecoder.Assign(&v.Active, nil, value); err != nil {
+			return err
+		}
+	case "Tags":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Tags, s0, value); err != nil {
+				return err
+			}
+		} else {
+			i0, err := strconv.Atoi(s0[0].Name)
+			if err != nil || i0 < 0 || i0 > anvil.DefaultMaxIndex {
+				return fmt.Errorf("invalid index %q of User.Tags", s0[0].Name)
+			}
+			if i0 >= len(v.Tags) {
+				v.Tags = append(v.Tags, make([]string, i0+1-len(v.Tags))...)
+			}
+			if len(s0[1:]) > 0 {
+				return fmt.Errorf("unexpected segment %q", s0[1:][0].Name)
+			}
+			if c, ok := value.(string); ok {
+				v.Tags[i0] = c
+			} else if err := anvilDecoder.Assign(&v.Tags[i0], nil, value); err != nil {
+				return err
+			}
+		}
+	case "Codes":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Codes, s0, value); err != nil {
+				return err
+			}
+		} else {
+			i0, err := strconv.Atoi(s0[0].Name)
+			if err != nil || i0 < 0 || i0 >= len(v.Codes) {
+				return fmt.Errorf("invalid index %q of User.Codes", s0[0].Name)
+			}
+			if len(s0[1:]) > 0 {
+				return fmt.Errorf("unexpected segment %q", s0[1:][0].Name)
+			}
+			if c, ok := value.(int16); ok {
+				v.Codes[i0] = c
+			} else if err := anvilDecoder.Assign(&v.Codes[i0], nil, value); err != nil {
+				return err
+			}
+		}
+	case "Address":
+		if len(segments[1:]) < 1 && value == nil {
+			v.Address = nil
+		} else {
+			if v.Address == nil {
+				v.Address = new(Address)
+			}
+			if err := unnotationAddress(v.Address, segments[1:], value); err != nil {
+				return err
+			}
+		}
+	case "Addresses":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Addresses, s0, value); err != nil {
+				return err
+			}
+		} else {
+			i0, err := strconv.Atoi(s0[0].Name)
+			if err != nil || i0 < 0 || i0 > anvil.DefaultMaxIndex {
+				return fmt.Errorf("invalid index %q of User.Addresses", s0[0].Name)
+			}
+			if i0 >= len(v.Addresses) {
+				v.Addresses = append(v.Addresses, make([]Address, i0+1-len(v.Addresses))...)
+			}
+			if err := unnotationAddress(&v.Addresses[i0], s0[1:], value); err != nil {
+				return err
+			}
+		}
+	case "Previous":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Previous, s0, value); err != nil {
+				return err
+			}
+		} else {
+			i0, err := strconv.Atoi(s0[0].Name)
+			if err != nil || i0 < 0 || i0 > anvil.DefaultMaxIndex {
+				return fmt.Errorf("invalid index %q of User.Previous", s0[0].Name)
+			}
+			if i0 >= len(v.Previous) {
+				v.Previous = append(v.Previous, make([]*Address, i0+1-len(v.Previous))...)
+			}
+			if len(s0[1:]) < 1 && value == nil {
+				v.Previous[i0] = nil
+			} else {
+				if v.Previous[i0] == nil {
+					v.Previous[i0] = new(Address)
+				}
+				if err := unnotationAddress(v.Previous[i0], s0[1:], value); err != nil {
+					return err
+				}
+			}
+		}
+	case "Phones":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Phones, s0, value); err != nil {
+				return err
+			}
+		} else {
+			m0 := s0[0].Name
+			if v.Phones == nil {
+				v.Phones = make(map[string]string)
+			}
+			e0 := v.Phones[m0]
+			if len(s0[1:]) > 0 {
+				return fmt.Errorf("unexpected segment %q", s0[1:][0].Name)
+			}
+			if c, ok := value.(string); ok {
+				e0 = c
+			} else if err := anvilDecoder.Assign(&e0, nil, value); err != nil {
+				return err
+			}
+			v.Phones[m0] = e0
+		}
+	case "Contacts":
+		if s0 := segments[1:]; len(s0) < 1 {
+			if err := anvilDecoder.Assign(&v.Contacts, s0, value); err != nil {
+				return err
+			}
+		} else {
+			b0, err := strconv.ParseInt(s0[0].Name, 10, 0)
+			if err != nil {
+				return err
+			}
+			m0 := int(b0)
+			if v.Contacts == nil {
+				v.Contacts = make(map[int]*Address)
+			}
+			e0 := v.Contacts[m0]
+			if len(s0[1:]) < 1 && value == nil {
+				e0 = nil
+			} else {
+				if e0 == nil {
+					e0 = new(Address)
+				}
+				if err := unnotationAddress(e0, s0[1:], value); err != nil {
+					return err
+				}
+			}
+			v.Contacts[m0] = e0
+		}
+	case "Meta":
+		if err := anvilDecoder.Assign(&v.Meta, segments[1:], value); err != nil {
+			return err
+		}
+	case "Created":
+		if err := anvilDecoder.Assign(&v.Created, segments[1:], value); err != nil {
+			return err
+		}
+	case "rank":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(int); ok {
+			v.Rank = c
+		} else if err := anvilDecoder.Assign(&v.Rank, nil, value); err != nil {
+			return err
+		}
+	case "Handle":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(uintptr); ok {
+			v.Handle = c
+		} else if err := anvilDecoder.Assign(&v.Handle, nil, value); err != nil {
+			return err
+		}
+	case "Backup":
+		if len(segments[1:]) < 1 && value == nil {
+			v.Backup = nil
+		} else {
+			if v.Backup == nil {
+				v.Backup = new(*Address)
+			}
+			if len(segments[1:]) < 1 && value == nil {
+				(*v.Backup) = nil
+			} else {
+				if (*v.Backup) == nil {
+					(*v.Backup) = new(Address)
+				}
+				if err := unnotationAddress((*v.Backup), segments[1:], value); err != nil {
+					return err
+				}
+			}
+		}
+	case "Share":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(int); ok {
+			v.Share = Pct(c)
+		} else if err := anvilDecoder.Assign(&v.Share, nil, value); err != nil {
+			return err
+		}
+	case "Trial":
+		if err := unnotationPeriod(&v.Trial, segments[1:], value); err != nil {
+			return err
+		}
+	default:
+		return anvilDecoder.Assign(v, segments, value)
+	}
+	return nil
+}
+
+func unnotationAddress(v *Address, segments []anvil.Segment, value interface{}) error {
+	if len(segments) < 1 {
+		return anvilDecoder.Assign(v, segments, value)
+	}
+	switch segments[0].Name {
+	case "city":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(string); ok {
+			v.City = c
+		} else if err := anvilDecoder.Assign(&v.City, nil, value); err != nil {
+			return err
+		}
+	case "-":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(string); ok {
+			v.Street = c
+		} else if err := anvilDecoder.Assign(&v.Street, nil, value); err != nil {
+			return err
+		}
+	case "Zip":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(uint); ok {
+			v.Zip = c
+		} else if err := anvilDecoder.Assign(&v.Zip, nil, value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("field %q not found in Address", segments[0].Name)
+	}
+	return nil
+}
+
+func unnotationAudit(v *Audit, segments []anvil.Segment, value interface{}) error {
+	if len(segments) < 1 {
+		return anvilDecoder.Assign(v, segments, value)
+	}
+	switch segments[0].Name {
+	case "Author":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(string); ok {
+			v.Author = c
+		} else if err := anvilDecoder.Assign(&v.Author, nil, value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("field %q not found in Audit", segments[0].Name)
+	}
+	return nil
+}
+
+func unnotationPeriod(v *Period, segments []anvil.Segment, value interface{}) error {
+	if len(segments) < 1 {
+		return anvilDecoder.Assign(v, segments, value)
+	}
+	switch segments[0].Name {
+	case "From":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(int); ok {
+			v.From = c
+		} else if err := anvilDecoder.Assign(&v.From, nil, value); err != nil {
+			return err
+		}
+	case "To":
+		if len(segments[1:]) > 0 {
+			return fmt.Errorf("unexpected segment %q", segments[1:][0].Name)
+		}
+		if c, ok := value.(int); ok {
+			v.To = c
+		} else if err := anvilDecoder.Assign(&v.To, nil, value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("field %q not found in Period", segments[0].Name)
+	}
+	return nil
+}
+
+// anvilNotation of a value by reflection
+func anvilNotation(items []anvil.Item, key string, v interface{}, glue string, mode anvil.Mode) ([]anvil.Item, error) {
+	n, err := (&anvil.Anvil{Mode: mode, Glue: glue}).NotationWithKey(key, v)
+	return append(items, n...), err
+}
+
+// anvilDecoder of notation keys
+var anvilDecoder = &anvil.Anvil{Glue: "."}
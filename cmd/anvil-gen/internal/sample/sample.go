@@ -0,0 +1,81 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sample of types used to check code generated by anvil-gen
+package sample
+
+import "time"
+
+//go:generate go run github.com/iveronanomi/anvil/cmd/anvil-gen
+
+type (
+	// Level of access
+	Level uint8
+	// Tags list
+	Tags []string
+	// Pct share of a user, negative is unknown
+	Pct int
+
+	// User of a service
+	//anvil:generate
+	User struct {
+		Audit
+		Name      string `json:"name"`
+		Age       int
+		Level     Level `json:"level,omitempty"`
+		Score     float32
+		Active    bool
+		Tags      Tags
+		Codes     [2]int16
+		Address   *Address
+		Addresses []Address
+		Previous  []*Address
+		Phones    map[string]string
+		Contacts  map[int]*Address
+		Meta      interface{}
+		Created   time.Time
+		Limits    Limits `json:",inline"`
+		Rank      int    `json:"rank,string"`
+		Secret    string `json:"-"`
+		Handle    uintptr
+		Backup    **Address
+		Share     Pct
+		Trial     Period
+		password  string
+	}
+
+	// Limits of a user inlined into user keys
+	Limits struct {
+		MaxItems int `json:"max_items"`
+	}
+
+	// Period of days, empty unless it ends after a start
+	Period struct {
+		From int
+		To   int
+	}
+
+	// Audit information
+	Audit struct {
+		Author string
+	}
+
+	// Address of a user
+	//anvil:generate
+	Address struct {
+		City   string `json:"city"`
+		Street string `json:"-,"`
+		Zip    uint
+	}
+)
+
+// IsZero share is unknown
+func (p Pct) IsZero() bool {
+	return p < 0
+}
+
+// IsZero period does not end after a start
+func (p Period) IsZero() bool {
+	return p.To <= p.From
+}
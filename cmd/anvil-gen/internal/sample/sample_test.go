@@ -0,0 +1,147 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sample
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iveronanomi/anvil"
+)
+
+func TestNotationUser_EqualsToReflection(t *testing.T) {
+	v := User{
+		Audit:     Audit{Author: "root"},
+		Name:      "John",
+		Level:     3,
+		Score:     .5,
+		Tags:      Tags{"a", "", "c"},
+		Codes:     [2]int16{0, -1},
+		Address:   &Address{City: "Paris"},
+		Addresses: []Address{{Zip: 75001}, {}},
+		Previous:  []*Address{{Street: "Main"}, nil},
+		Phones:    map[string]string{"home": "1", "work": ""},
+		Contacts:  map[int]*Address{-1: {City: "Rome"}, 2: {}, 3: nil},
+		Meta:      map[string]int{"one": 1},
+		Created:   time.Date(2019, 4, 22, 15, 49, 32, 0, time.UTC),
+		Limits:    Limits{MaxItems: 10},
+		Rank:      7,
+		Secret:    "secret",
+		Handle:    0xff,
+		Backup:    new(*Address),
+		Share:     -1,
+		Trial:     Period{From: 2, To: 1},
+		password:  "secret",
+	}
+	for _, mode := range []anvil.Mode{anvil.NoSkipEmpty, anvil.SkipEmpty} {
+		expected, err := anvil.Notation(v, mode, ".")
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		occurred, err := NotationUser(&v, ".", mode)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		check(t, expected, occurred)
+	}
+}
+
+func TestNotationUser_WithZeroValue(t *testing.T) {
+	for _, mode := range []anvil.Mode{anvil.NoSkipEmpty, anvil.SkipEmpty} {
+		expected, err := anvil.Notation(User{}, mode, "__")
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		occurred, err := NotationUser(&User{}, "__", mode)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		check(t, expected, occurred)
+	}
+}
+
+// check items are equal in the same order, map keys are sorted the same way
+func check(t *testing.T, expected, occurred []anvil.Item) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestUnnotationUser_RoundTrip(t *testing.T) {
+	backup := &Address{City: "Nice"}
+	expected := User{
+		Audit:     Audit{Author: "root"},
+		Name:      "John",
+		Age:       42,
+		Level:     3,
+		Score:     .5,
+		Active:    true,
+		Tags:      Tags{"a", "b"},
+		Codes:     [2]int16{1, -1},
+		Address:   &Address{City: "Paris", Street: "Rivoli", Zip: 75001},
+		Addresses: []Address{{Zip: 1}, {City: "Lyon"}},
+		Previous:  []*Address{{Street: "Main"}},
+		Phones:    map[string]string{"home": "1", "work": "2"},
+		Contacts:  map[int]*Address{-1: {City: "Rome"}},
+		Meta:      "meta",
+		Limits:    Limits{MaxItems: 10},
+		Rank:      7,
+		Handle:    0xff,
+		Backup:    &backup,
+	}
+	items, err := NotationUser(&expected, ".", anvil.SkipEmpty)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var reflected User
+	if err = (&anvil.Anvil{Glue: "."}).Unnotation(items, &reflected); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred User
+
+	err = UnnotationUser(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+	if !reflect.DeepEqual(reflected, occurred) {
+		t.Errorf("reflected %#v, occurred %#v", reflected, occurred)
+	}
+}
+
+func TestUnnotationUser_Errors(t *testing.T) {
+	cases := map[string]anvil.Item{
+		"another root":    {Key: "Address.city", Value: "Paris"},
+		"root of a name":  {Key: "Username", Value: "John"},
+		"unknown field":   {Key: "User.Unknown", Value: 1},
+		"invalid index":   {Key: "User.Tags[a]", Value: "a"},
+		"out of array":    {Key: "User.Codes[2]", Value: 1},
+		"huge index":      {Key: "User.Tags[9223372036854775806]", Value: "a"},
+		"invalid map key": {Key: "User.Contacts[a].city", Value: "Paris"},
+		"invalid value":   {Key: "User.Age", Value: "old"},
+		"nested scalar":   {Key: "User.Age.Years", Value: 1},
+	}
+	for name, item := range cases {
+		if err := UnnotationUser([]anvil.Item{item}, &User{}); err == nil {
+			t.Errorf("%s: error expected", name)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command anvil-gen generates reflection-free notation and unnotation functions
+// for structure types annotated with the `anvil:generate` comment.
+//
+// Usage in a package source:
+//
+//	//go:generate anvil-gen
+//
+//	// User of a service
+//	//anvil:generate
+//	type User struct {
+//		Name string `json:"name"`
+//	}
+//
+// For every annotated type T a function
+//
+//	func NotationT(v *T, glue string, mode anvil.Mode) ([]anvil.Item, error)
+//
+// is generated, it produces the same items as anvil.Notation
+// without registered modifiers and empty predicates, `IsZero() bool` methods
+// of types of a package are honored, and a function
+//
+//	func UnnotationT(items []anvil.Item, v *T) error
+//
+// decoding items with keys glued by -glue flag value (`.` by default)
+// the same way as anvil.Anvil.Unnotation does.
+// Field names and options are taken from tags listed by -tags flag (`json` by default).
+// Generated functions do not detect cycles of pointers, values must be acyclic.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	output := flag.String("output", "anvil_gen.go", "output file name inside of a package directory")
+	glue := flag.String("glue", ".", "glue of keys for unnotation functions")
+	tags := flag.String("tags", "json", "comma separated tags of field names consulted in order")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: anvil-gen [-output file] [-glue glue] [-tags tags] [directory]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+	if err := run(dir, *output, *glue, strings.Split(*tags, ",")); err != nil {
+		fmt.Fprintln(os.Stderr, "anvil-gen:", err)
+		os.Exit(1)
+	}
+}
+
+// run generation for a package in dir
+func run(dir, output, glue string, tags []string) error {
+	if len(tags) == 1 && tags[0] == "json" {
+		tags = nil
+	}
+	src, err := Generate(dir, output, glue, tags)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, output), src, 0644)
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import "reflect"
+
+// Context of a modified value
+type Context struct {
+	// Key of a value
+	Key string
+	// Parent structure, array, slice or map of a value, invalid for a root value
+	Parent reflect.Value
+	// Field of a parent structure with tags, zero for elements of arrays, slices and maps
+	Field reflect.StructField
+	// Anvil configuration of a notation
+	Anvil *Anvil
+}
+
+// modifierFunc of a value in a context
+type modifierFunc func(ctx Context, f reflect.Value) (interface{}, bool, error)
+
+// plain modifier ignoring a context
+func plain(mod func(f reflect.Value) (interface{}, bool, error)) modifierFunc {
+	return func(_ Context, f reflect.Value) (interface{}, bool, error) {
+		return mod(f)
+	}
+}
+
+// RegisterContextModifierFunc - assign a modifier function of a type receiving a context of a value:
+// a key, a parent value, a structure field with tags and a configuration,
+// e.g. to format a value by a tag `anvil:",format=unix"`, registered same as RegisterModifierFunc
+func (s *Anvil) RegisterContextModifierFunc(t interface{}, mod func(ctx Context, f reflect.Value) (interface{}, bool, error)) *Anvil {
+	return s.register(t, mod)
+}
+
+// context of a value by a key on a trail
+func (t trail) context(s *Anvil, key string) Context {
+	ctx := Context{Key: key, Parent: t.parent, Anvil: s}
+	if t.index != nil {
+		ctx.Field = t.parent.Type().FieldByIndex(t.index)
+	}
+	return ctx
+}
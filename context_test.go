@@ -0,0 +1,75 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Schedule struct {
+	Start   time.Time `anvil:",format=unix"`
+	End     time.Time
+	Breaks  []time.Time
+	Options map[string]time.Time
+}
+
+func TestAnvil_RegisterContextModifierFunc(t *testing.T) {
+	clock := time.Date(2019, 4, 22, 15, 49, 32, 0, time.UTC)
+	v := Schedule{Start: clock, End: clock, Breaks: []time.Time{clock}, Options: map[string]time.Time{"a": clock}}
+	var contexts []Context
+	a := &Anvil{Glue: "."}
+	a.RegisterContextModifierFunc(time.Time{}, func(ctx Context, f reflect.Value) (interface{}, bool, error) {
+		contexts = append(contexts, ctx)
+		value := f.Interface().(time.Time)
+		if strings.Contains(ctx.Field.Tag.Get("anvil"), "format=unix") {
+			return value.Unix(), value.IsZero(), nil
+		}
+		return value.Format("2006-01-02"), value.IsZero(), nil
+	})
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Schedule.Start", Value: clock.Unix()},
+		{Key: "Schedule.End", Value: "2019-04-22"},
+		{Key: "Schedule.Breaks[0]", Value: "2019-04-22"},
+		{Key: "Schedule.Options[a]", Value: "2019-04-22"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+	parents := []reflect.Kind{reflect.Struct, reflect.Struct, reflect.Slice, reflect.Map}
+	fields := []string{"Start", "End", "", ""}
+	for i, ctx := range contexts {
+		if ctx.Key != expected[i].Key || ctx.Parent.Kind() != parents[i] || ctx.Field.Name != fields[i] || ctx.Anvil != a {
+			t.Errorf("unexpected context %d %#v", i, ctx)
+		}
+	}
+}
+
+func TestAnvil_RegisterContextModifierFunc_Root(t *testing.T) {
+	a := &Anvil{Glue: "."}
+	a.RegisterContextModifierFunc(time.Time{}, func(ctx Context, f reflect.Value) (interface{}, bool, error) {
+		return ctx.Parent.IsValid(), false, nil
+	})
+
+	r, err := a.Notation(time.Time{})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "Time", Value: false}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// CyclePolicy of values referencing own ancestors by pointers, maps or slices
+type CyclePolicy int
+
+const (
+	// CycleError stops notation with an error pointing to a cycle
+	CycleError CyclePolicy = iota
+	// CycleSkip values closing a cycle
+	CycleSkip
+	// CycleRef item `key.$ref` with a key of a first occurrence of a value
+	CycleRef
+)
+
+// visit of a container by an address, a type and a length of slices
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// identity of a container value which could close a cycle
+func identity(v reflect.Value) (visit, bool) {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array:
+		if v.CanAddr() {
+			return visit{ptr: v.UnsafeAddr(), typ: v.Type()}, true
+		}
+	case reflect.Map:
+		if !v.IsNil() {
+			return visit{ptr: v.Pointer(), typ: v.Type()}, true
+		}
+	case reflect.Slice:
+		if !v.IsNil() && v.Len() > 0 {
+			return visit{ptr: v.Pointer(), typ: v.Type(), len: v.Len()}, true
+		}
+	}
+	return visit{}, false
+}
+
+// cycle items of a key referencing a value of a first key by policy
+func (s *Anvil) cycle(f KeyFormatter, key, first string) ([]Item, error) {
+	switch s.Cycles {
+	case CycleSkip:
+		return nil, nil
+	case CycleRef:
+		return []Item{{Key: f.Field(key, "$ref"), Value: first}}, nil
+	}
+	return nil, fmt.Errorf("anvil:cycle of %s to %s", key, first)
+}
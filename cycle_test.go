@@ -0,0 +1,83 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"testing"
+)
+
+type Node struct {
+	Name string
+	Next *Node
+}
+
+func TestAnvil_Notation_WithCycles(t *testing.T) {
+	first := &Node{Name: "first"}
+	first.Next = &Node{Name: "second", Next: first}
+	cases := []struct {
+		policy   CyclePolicy
+		expected []Item
+	}{
+		{
+			policy: CycleSkip,
+			expected: []Item{
+				{Key: "Node.Name", Value: "first"},
+				{Key: "Node.Next.Name", Value: "second"},
+			},
+		},
+		{
+			policy: CycleRef,
+			expected: []Item{
+				{Key: "Node.Name", Value: "first"},
+				{Key: "Node.Next.Name", Value: "second"},
+				{Key: "Node.Next.Next.$ref", Value: "Node"},
+			},
+		},
+	}
+	for _, c := range cases {
+		a := &Anvil{Mode: SkipEmpty, Glue: ".", Cycles: c.policy}
+
+		r, err := a.Notation(first)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		check(t, c.expected, r)
+	}
+}
+
+func TestAnvil_Notation_WithCycles_ExpectedError(t *testing.T) {
+	nested := &Nested{}
+	nested.Nested = nested
+	m := map[string]interface{}{}
+	m["self"] = m
+	s := []interface{}{nil}
+	s[0] = s
+	for _, v := range []interface{}{nested, m, s} {
+		if _, err := (&Anvil{Glue: "."}).Notation(v); err == nil {
+			t.Errorf("error expected for %T", v)
+		}
+	}
+}
+
+func TestAnvil_Notation_WithSharedPointers(t *testing.T) {
+	shared := &Backend{Host: "a"}
+	v := struct {
+		Primary, Secondary *Backend
+	}{Primary: shared, Secondary: shared}
+	expected := []Item{
+		{Key: "Primary.Host", Value: "a"},
+		{Key: "Secondary.Host", Value: "a"},
+	}
+
+	r, err := (&Anvil{Mode: SkipEmpty, Glue: "."}).Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
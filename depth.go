@@ -0,0 +1,49 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TruncatePolicy of containers nested deeper than MaxDepth
+type TruncatePolicy int
+
+const (
+	// TruncateValue container to a single item with a container value
+	TruncateValue TruncatePolicy = iota
+	// TruncateSkip container with nested values
+	TruncateSkip
+)
+
+// truncated container value at a depth of a path
+func (s *Anvil) truncated(v reflect.Value, path trail) bool {
+	if s.MaxDepth < 1 || path.depth < s.MaxDepth {
+		return false
+	}
+	// modified values are not walked, so never truncated
+	switch v.Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// truncate container to an item of opaque value by policy,
+// empty containers and structures of empty fields are skipped in SkipEmpty mode
+func (s *Anvil) truncate(key string, v reflect.Value, skip bool) []Item {
+	if s.Truncate == TruncateSkip {
+		return nil
+	}
+	if skip && s.empty(v) {
+		return nil
+	}
+	if !v.CanInterface() {
+		// values of unexported fields are represented as strings
+		return []Item{{Key: key, Value: fmt.Sprint(v)}}
+	}
+	return []Item{{Key: key, Value: v.Interface()}}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iveronanomi/anvil/modifier"
+)
+
+type Order struct {
+	ID       int
+	Customer Backend
+	Lines    []Line
+	Tags     map[string]string
+	Created  time.Time
+	notes    []string
+}
+
+type Line struct {
+	SKU      string
+	Quantity int
+}
+
+func TestAnvil_Notation_WithMaxDepth(t *testing.T) {
+	clock := time.Date(2019, 4, 22, 15, 49, 32, 0, time.UTC)
+	v := Order{
+		ID:       1,
+		Customer: Backend{Host: "a"},
+		Lines:    []Line{{SKU: "x", Quantity: 2}},
+		Created:  clock,
+		notes:    []string{"fragile"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", MaxDepth: 1}
+	a.RegisterModifierFunc(time.Time{}, modifier.Time)
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Order.ID", Value: 1},
+		{Key: "Order.Customer", Value: v.Customer},
+		{Key: "Order.Lines", Value: v.Lines},
+		{Key: "Order.Created", Value: clock.Format(time.RFC3339Nano)},
+		{Key: "Order.notes", Value: "[fragile]"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_WithMaxDepth_TruncateSkip(t *testing.T) {
+	type Cart struct {
+		ID       int
+		Customer Backend
+		Lines    []Line
+	}
+	v := Cart{
+		ID:       1,
+		Customer: Backend{Host: "a"},
+		Lines:    []Line{{SKU: "x", Quantity: 2}},
+	}
+	expected := []Item{
+		{Key: "Cart.ID", Value: 1},
+		{Key: "Cart.Customer.Host", Value: "a"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", MaxDepth: 2, Truncate: TruncateSkip}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"errors"
+	"reflect"
+)
+
+// zeroer of a value with own emptiness, e.g. time.Time
+type zeroer interface {
+	IsZero() bool
+}
+
+// RegisterEmptyFunc - assign an emptiness predicate of a type used by SkipEmpty mode and omitempty option,
+// predicates take precedence over an `IsZero() bool` method of a type
+func (s *Anvil) RegisterEmptyFunc(t interface{}, empty func(f reflect.Value) bool) *Anvil {
+	typ := reflect.TypeOf(t)
+	if typ == nil {
+		return s.fail(errors.New("anvil:empty predicate of a nil type"))
+	}
+	if s.empties == nil {
+		s.empties = make(map[reflect.Type]func(f reflect.Value) bool)
+	}
+	s.empties[typ] = empty
+	return s
+}
+
+// zero value by a predicate of a type or by IsZero method, defined is false without both,
+// interface values are checked by a dynamic value
+func (s *Anvil) zero(v reflect.Value) (zero, defined bool) {
+	if !v.IsValid() || v.Kind() == reflect.Interface {
+		return false, false
+	}
+	if fn, ok := s.empties[v.Type()]; ok {
+		return fn(v), true
+	}
+	if !v.CanInterface() {
+		return false, false
+	}
+	if z, ok := v.Interface().(zeroer); ok {
+		return z.IsZero(), true
+	}
+	if v.CanAddr() {
+		if z, ok := v.Addr().Interface().(zeroer); ok {
+			return z.IsZero(), true
+		}
+	}
+	return false, false
+}
+
+// empty value by a predicate or IsZero method, zero scalars, nil pointers and interfaces,
+// empty slices and maps, structures and arrays of empty elements
+func (s *Anvil) empty(v reflect.Value) bool {
+	if zero, ok := s.zero(v); ok {
+		return zero
+	}
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Struct, reflect.Array:
+		for _, e := range elements(v) {
+			if !s.empty(e) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() < 1
+	case reflect.Ptr, reflect.Interface, reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		// pointed values are not checked, pointers of cycles are never empty
+		return v.IsNil()
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Complex64, reflect.Complex128:
+		return v.Complex() == 0
+	}
+	return false
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iveronanomi/anvil/modifier"
+)
+
+type Version struct {
+	Major int
+	Minor int
+}
+
+func (v Version) IsZero() bool {
+	return v.Major == 0
+}
+
+type Percent int
+
+func (p *Percent) IsZero() bool {
+	return *p < 0
+}
+
+type Release struct {
+	Name     string
+	Version  Version
+	Coverage Percent
+	Date     time.Time
+	Window   Window
+}
+
+type Window struct {
+	From, To int
+}
+
+func TestAnvil_Notation_SkipEmpty_IsZero(t *testing.T) {
+	v := &Release{Version: Version{Minor: 2}, Coverage: 0}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "Release.Coverage", Value: 0}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+
+	v.Coverage = -1
+	if r, err = a.Notation(v); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(r) > 0 {
+		t.Errorf("expected no items, occurred %#v", r)
+	}
+}
+
+func TestAnvil_RegisterEmptyFunc(t *testing.T) {
+	v := Release{Name: "n/a", Version: Version{Major: 1}, Window: Window{To: 1}}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	a.RegisterEmptyFunc("", func(v reflect.Value) bool {
+		return v.Len() < 1 || v.String() == "n/a"
+	}).RegisterEmptyFunc(Version{}, func(v reflect.Value) bool {
+		return v.Field(1).Int() == 0
+	}).RegisterEmptyFunc(Window{}, func(v reflect.Value) bool {
+		return v.Field(0).Int() == 0
+	})
+
+	r, err := a.Notation(&v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "Release.Coverage", Value: 0}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_NoSkipEmpty_WithoutPredicates(t *testing.T) {
+	var calls int
+	a := &Anvil{Mode: NoSkipEmpty, Glue: "."}
+	a.RegisterEmptyFunc(Window{}, func(v reflect.Value) bool {
+		calls++
+		return true
+	})
+
+	_, err := a.Notation(Release{})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if calls > 0 {
+		t.Errorf("expected no calls of a predicate, occurred %d", calls)
+	}
+}
+
+func TestAnvil_RegisterEmptyFunc_ExpectedError(t *testing.T) {
+	a := &Anvil{Glue: "."}
+	a.RegisterEmptyFunc(nil, func(reflect.Value) bool { return true })
+
+	_, err := a.Notation(1)
+
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestAnvil_Notation_SkipEmpty_TruncatedStructures(t *testing.T) {
+	type Plan struct {
+		Releases []Release
+		Windows  []Window
+	}
+	v := Plan{Releases: []Release{{Coverage: -1}}, Windows: []Window{{}, {From: 1}}}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", MaxDepth: 2}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "Plan.Windows[1]", Value: Window{From: 1}}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_SkipEmpty_ModifiedFields(t *testing.T) {
+	type Inner struct {
+		A      int
+		Status Status
+	}
+	type Wrap struct {
+		In Inner
+		X  int
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	a.RegisterModifierFunc(0, func(v reflect.Value) (interface{}, bool, error) {
+		return "ZERO", false, nil
+	}).RegisterModifierFunc(Status(0), modifier.String)
+
+	r, err := a.Notation(Wrap{})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Wrap.In.A", Value: "ZERO"},
+		{Key: "Wrap.In.Status", Value: "INACTIVE"},
+		{Key: "Wrap.X", Value: "ZERO"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
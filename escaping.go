@@ -0,0 +1,103 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Escaping of glue and bracket characters inside of field names and map keys
+type Escaping int
+
+const (
+	// NoEscaping names and keys are used as is
+	NoEscaping Escaping = iota
+	// QuoteEscaping names and keys with special characters are quoted as `a["b.c"]`
+	QuoteEscaping
+	// BackslashEscaping special characters are escaped by a backslash as `a[b\.c]`
+	BackslashEscaping
+)
+
+// escape name with a special strings
+func (e Escaping) escape(name string, special ...string) string {
+	switch e {
+	case QuoteEscaping:
+		if strings.HasPrefix(name, `"`) || contains(name, special) {
+			return strconv.Quote(name)
+		}
+	case BackslashEscaping:
+		if !strings.Contains(name, `\`) && !contains(name, special) {
+			return name
+		}
+		var b strings.Builder
+		for i := 0; i < len(name); i++ {
+			if name[i] == '\\' {
+				b.WriteString(`\\`)
+				continue
+			}
+			for _, s := range special {
+				if len(s) > 0 && strings.HasPrefix(name[i:], s) {
+					b.WriteByte('\\')
+					break
+				}
+			}
+			b.WriteByte(name[i])
+		}
+		return b.String()
+	}
+	return name
+}
+
+// scan key for a name until one of stop strings,
+// returns unescaped name and the rest of a key
+func (e Escaping) scan(key string, stop ...string) (string, string, error) {
+	if e == QuoteEscaping && strings.HasPrefix(key, `"`) {
+		return unquote(key)
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if e == BackslashEscaping && key[i] == '\\' {
+			if i+1 >= len(key) {
+				return "", "", errors.New("anvil:dangling backslash in " + strconv.Quote(key))
+			}
+			i++
+			b.WriteByte(key[i])
+			continue
+		}
+		for _, s := range stop {
+			if len(s) > 0 && strings.HasPrefix(key[i:], s) {
+				return b.String(), key[i:], nil
+			}
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String(), "", nil
+}
+
+// unquote leading quoted string of a key, returns the string and the rest of a key
+func unquote(key string) (string, string, error) {
+	for i := 1; i < len(key); i++ {
+		switch key[i] {
+		case '\\':
+			i++
+		case '"':
+			name, err := strconv.Unquote(key[:i+1])
+			return name, key[i+1:], err
+		}
+	}
+	return "", "", errors.New("anvil:unclosed quote in " + strconv.Quote(key))
+}
+
+// contains any of a special strings
+func contains(name string, special []string) bool {
+	for _, s := range special {
+		if len(s) > 0 && strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
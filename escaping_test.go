@@ -0,0 +1,95 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Escaped struct {
+	Dotted string            `json:"a.b"`
+	M      map[string]string `json:"m"`
+}
+
+func TestAnvil_Notation_WithQuoteEscaping(t *testing.T) {
+	v := Escaped{Dotted: "x", M: map[string]string{"b.c": "y"}}
+	expected := []Item{
+		{Key: `Escaped."a.b"`, Value: "x"},
+		{Key: `Escaped.m["b.c"]`, Value: "y"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Escaping: QuoteEscaping}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithBackslashEscaping(t *testing.T) {
+	v := Escaped{Dotted: "x", M: map[string]string{`b.c]\`: "y"}}
+	expected := []Item{
+		{Key: `Escaped.a\.b`, Value: "x"},
+		{Key: `Escaped.m[b\.c\]\\]`, Value: "y"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Escaping: BackslashEscaping}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Unnotation_WithEscaping(t *testing.T) {
+	expected := Escaped{Dotted: "x", M: map[string]string{"b.c": "1", "[d]": "2", `"e"`: "3", "": "4"}}
+	formatters := []KeyFormatter{
+		BracketFormatter{Glue: ".", Escaping: QuoteEscaping},
+		BracketFormatter{Glue: ".", Escaping: BackslashEscaping},
+		SeparatorFormatter{Separator: ".", Escaping: QuoteEscaping},
+		SeparatorFormatter{Separator: ".", Escaping: BackslashEscaping},
+	}
+	for _, f := range formatters {
+		a := &Anvil{Mode: SkipEmpty, Formatter: f}
+		items, err := a.Notation(expected)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		var occurred Escaped
+
+		err = a.Unnotation(items, &occurred)
+
+		if err != nil {
+			t.Errorf("%#v: %v", f, err)
+			continue
+		}
+		if !reflect.DeepEqual(expected, occurred) {
+			t.Errorf("%#v: expected %#v, occurred %#v", f, expected, occurred)
+		}
+	}
+}
+
+func TestAnvil_Split_WithEscapingErrors(t *testing.T) {
+	cases := []struct {
+		formatter KeyFormatter
+		key       string
+	}{
+		{formatter: BracketFormatter{Glue: ".", Escaping: QuoteEscaping}, key: `.m["b.c]`},
+		{formatter: BracketFormatter{Glue: ".", Escaping: QuoteEscaping}, key: `.m["b"c]`},
+		{formatter: BracketFormatter{Glue: ".", Escaping: BackslashEscaping}, key: `.m[b\`},
+		{formatter: SeparatorFormatter{Separator: ".", Escaping: BackslashEscaping}, key: `.m.b\`},
+	}
+	for _, c := range cases {
+		if _, err := c.formatter.Split(c.key); err == nil {
+			t.Errorf("%#v: error expected for %q", c.formatter, c.key)
+		}
+	}
+}
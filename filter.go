@@ -0,0 +1,87 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"path"
+)
+
+// filter of paths by Include and Exclude patterns split to segments
+type filter struct {
+	include [][]Segment
+	exclude [][]Segment
+}
+
+// compile Include and Exclude patterns of keys
+func (s *Anvil) compile() (*filter, error) {
+	f := &filter{}
+	for _, p := range s.Include {
+		segments, err := s.Split(p)
+		if err != nil {
+			return nil, fmt.Errorf("anvil:include pattern %q: %v", p, err)
+		}
+		f.include = append(f.include, segments)
+	}
+	for _, p := range s.Exclude {
+		segments, err := s.Split(p)
+		if err != nil {
+			return nil, fmt.Errorf("anvil:exclude pattern %q: %v", p, err)
+		}
+		f.exclude = append(f.exclude, segments)
+	}
+	return f, nil
+}
+
+// visit path of a trail, false if a path is excluded
+// or could not lead to a path of Include patterns
+func (t *trail) visit() bool {
+	if t.filter == nil {
+		return true
+	}
+	for _, p := range t.filter.exclude {
+		if matched, _ := match(p, t.segments); matched {
+			return false
+		}
+	}
+	if t.included {
+		return true
+	}
+	var partial bool
+	for _, p := range t.filter.include {
+		matched, nested := match(p, t.segments)
+		if matched {
+			t.included = true
+			return true
+		}
+		partial = partial || nested
+	}
+	return partial
+}
+
+// match segments of a path by a pattern, returns whether a pattern matches a path
+// and whether a pattern could match nested paths of it,
+// `*` matches a single segment, `**` matches any number of segments,
+// names of segments are matched by path.Match
+func match(pattern, segments []Segment) (matched, nested bool) {
+	if len(pattern) < 1 {
+		return len(segments) < 1, false
+	}
+	if pattern[0].Name == "**" {
+		matched, nested = match(pattern[1:], segments)
+		if len(segments) > 0 {
+			m, n := match(pattern, segments[1:])
+			matched, nested = matched || m, nested || n
+		}
+		return matched, nested || len(segments) < 1
+	}
+	if len(segments) < 1 {
+		return false, true
+	}
+	if ok, err := path.Match(pattern[0].Name, segments[0].Name); err != nil || !ok {
+		return false, false
+	}
+	return match(pattern[1:], segments[1:])
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"testing"
+)
+
+type (
+	Account struct {
+		Login    string
+		password string
+		Profile  Profile
+		Events   chan string
+	}
+	Profile struct {
+		Email    string
+		password string
+	}
+	Orders   []Purchase
+	Purchase struct {
+		ID    int
+		Items []Line
+	}
+)
+
+func TestAnvil_Notation_WithExclude(t *testing.T) {
+	v := Account{
+		Login:    "root",
+		password: "secret",
+		Profile:  Profile{Email: "root@localhost", password: "secret"},
+		Events:   make(chan string),
+	}
+	expected := []Item{
+		{Key: "Account.Login", Value: "root"},
+		{Key: "Account.Profile.Email", Value: "root@localhost"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Exclude: []string{"**.password", "Account.Events"}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithInclude(t *testing.T) {
+	v := Orders{
+		{ID: 1, Items: []Line{{SKU: "a", Quantity: 1}, {SKU: "b", Quantity: 2}}},
+		{ID: 2, Items: []Line{{SKU: "c", Quantity: 3}}},
+	}
+	expected := []Item{
+		{Key: "Orders[0].Items[0].SKU", Value: "a"},
+		{Key: "Orders[0].Items[1].SKU", Value: "b"},
+		{Key: "Orders[1].ID", Value: 2},
+		{Key: "Orders[1].Items[0].SKU", Value: "c"},
+	}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: ".", Include: []string{"Orders[*].Items[*].SKU", "Orders[1].ID"}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithIncludeSubtree(t *testing.T) {
+	v := Account{Login: "root", Profile: Profile{Email: "root@localhost"}}
+	expected := []Item{
+		{Key: "Account.Profile.Email", Value: "root@localhost"},
+		{Key: "Account.Profile.password", Value: ""},
+	}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: ".", Include: []string{"Account.Prof*"}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithInvalidPattern(t *testing.T) {
+	a := &Anvil{Glue: ".", Exclude: []string{"Account.Profile[*"}}
+
+	if _, err := a.Notation(Account{}); err == nil {
+		t.Error("error expected")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, key    string
+		matched, nested bool
+	}{
+		{pattern: "User.*", key: "User", matched: false, nested: true},
+		{pattern: "User.*", key: "User.Name", matched: true, nested: false},
+		{pattern: "User.*", key: "User.Address.City", matched: false, nested: false},
+		{pattern: "**.password", key: "User", matched: false, nested: true},
+		{pattern: "**.password", key: "User.Address.password", matched: true, nested: true},
+		{pattern: "**", key: "User.Address", matched: true, nested: true},
+		{pattern: "User[*]", key: "User[key]", matched: true, nested: false},
+	}
+	a := &Anvil{Glue: "."}
+	for _, c := range cases {
+		pattern, err := a.Split(c.pattern)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		segments, err := a.Split(c.key)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		matched, nested := match(pattern, segments)
+
+		if matched != c.matched || nested != c.nested {
+			t.Errorf("%s by %s: expected %v %v, occurred %v %v", c.key, c.pattern, c.matched, c.nested, matched, nested)
+		}
+	}
+}
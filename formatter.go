@@ -0,0 +1,151 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type (
+	// KeyFormatter of notation keys, used to glue segments of keys
+	// and to split keys back by decoders
+	KeyFormatter interface {
+		// Field key of a structure field with a name
+		Field(prefix, name string) string
+		// Index key of an array or a slice element
+		Index(prefix string, idx int) string
+		// MapKey key of a map element with string representation of a map key
+		MapKey(prefix, key string) string
+		// Split key (without a type name prefix) to a list of segments
+		Split(key string) ([]Segment, error)
+	}
+	// BracketFormatter of keys as `a.b[0][key]`, default formatter of Anvil
+	BracketFormatter struct {
+		// Glue string to glue fields
+		Glue string
+		// Escaping of glue and brackets inside of names and keys
+		Escaping Escaping
+	}
+	// SeparatorFormatter of keys as `a.b.0.key` (Spring/Helm style),
+	// `a__b__0__key` (environment variables style) or `a/b/0/key` (KV store style)
+	SeparatorFormatter struct {
+		// Separator of all segments
+		Separator string
+		// Escaping of separator inside of names and keys
+		Escaping Escaping
+	}
+)
+
+// Field key of a structure field as `prefix.name`, `name` for an empty prefix
+func (f BracketFormatter) Field(prefix, name string) string {
+	name = f.Escaping.escape(name, f.Glue, "[", "]")
+	if len(prefix) < 1 {
+		return name
+	}
+	return prefix + f.Glue + name
+}
+
+// Index key of an array or a slice element as `prefix[idx]`
+func (f BracketFormatter) Index(prefix string, idx int) string {
+	return arrayPrefix(prefix, idx)
+}
+
+// MapKey key of a map element as `prefix[key]`
+func (f BracketFormatter) MapKey(prefix, key string) string {
+	return prefix + "[" + f.Escaping.escape(key, f.Glue, "[", "]") + "]"
+}
+
+// Split key (without a type name prefix) to a list of segments,
+// bracket segments are indexes and map keys, glue of a first name is optional
+func (f BracketFormatter) Split(key string) ([]Segment, error) {
+	var (
+		segments []Segment
+		name     string
+		err      error
+	)
+	for len(key) > 0 {
+		if key[0] == '[' {
+			if name, key, err = f.Escaping.scan(key[1:], "]"); err != nil {
+				return nil, err
+			}
+			if !strings.HasPrefix(key, "]") {
+				return nil, fmt.Errorf("anvil:unclosed bracket in %q", key)
+			}
+			segments = append(segments, Segment{Name: name, Bracket: true})
+			key = key[1:]
+			continue
+		}
+		if len(f.Glue) > 0 && (len(segments) > 0 || strings.HasPrefix(key, f.Glue)) {
+			if !strings.HasPrefix(key, f.Glue) {
+				return nil, fmt.Errorf("anvil:unexpected %q, glue %q expected", key, f.Glue)
+			}
+			key = key[len(f.Glue):]
+		}
+		if name, key, err = f.Escaping.scan(key, f.Glue, "["); err != nil {
+			return nil, err
+		}
+		segments = append(segments, Segment{Name: name})
+	}
+	return segments, nil
+}
+
+// Field key of a structure field as `prefix.name`
+func (f SeparatorFormatter) Field(prefix, name string) string {
+	return f.glue(prefix, f.Escaping.escape(name, f.Separator))
+}
+
+// Index key of an array or a slice element as `prefix.idx`
+func (f SeparatorFormatter) Index(prefix string, idx int) string {
+	return f.glue(prefix, strconv.Itoa(idx))
+}
+
+// MapKey key of a map element as `prefix.key`
+func (f SeparatorFormatter) MapKey(prefix, key string) string {
+	return f.glue(prefix, f.Escaping.escape(key, f.Separator))
+}
+
+// glue segment to a prefix, segment is not separated from an empty prefix
+func (f SeparatorFormatter) glue(prefix, segment string) string {
+	if len(prefix) < 1 {
+		return segment
+	}
+	return prefix + f.Separator + segment
+}
+
+// Split key (without a type name prefix) to a list of segments,
+// numeric segments are treated as indexes, separator of a first segment is optional
+func (f SeparatorFormatter) Split(key string) ([]Segment, error) {
+	if len(f.Separator) < 1 {
+		return nil, fmt.Errorf("anvil:empty separator could not split %q", key)
+	}
+	var (
+		segments []Segment
+		name     string
+		err      error
+	)
+	for len(key) > 0 {
+		if strings.HasPrefix(key, f.Separator) {
+			key = key[len(f.Separator):]
+		} else if len(segments) > 0 {
+			return nil, fmt.Errorf("anvil:unexpected %q, separator %q expected", key, f.Separator)
+		}
+		if name, key, err = f.Escaping.scan(key, f.Separator); err != nil {
+			return nil, err
+		}
+		_, err = strconv.ParseUint(name, 10, 64)
+		segments = append(segments, Segment{Name: name, Bracket: err == nil})
+	}
+	return segments, nil
+}
+
+// formatter of keys, BracketFormatter with a Glue and Escaping by default
+func (s *Anvil) formatter() KeyFormatter {
+	if s.Formatter != nil {
+		return s.Formatter
+	}
+	return BracketFormatter{Glue: s.Glue, Escaping: s.Escaping}
+}
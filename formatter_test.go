@@ -0,0 +1,91 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnvil_Notation_WithSeparatorFormatter(t *testing.T) {
+	v := Config{
+		Name:     "service",
+		Tags:     []string{"one"},
+		Limits:   map[string]int64{"cpu": 2},
+		Backends: []*Backend{{Host: "a"}},
+	}
+	expected := []Item{
+		{Key: "Config__name", Value: "service"},
+		{Key: "Config__Tags__0", Value: "one"},
+		{Key: "Config__Limits__cpu", Value: int64(2)},
+		{Key: "Config__Backends__0__Host", Value: "a"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Formatter: SeparatorFormatter{Separator: "__"}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Unnotation_WithSeparatorFormatter(t *testing.T) {
+	expected := Config{
+		Name:     "service",
+		Tags:     []string{"one", "two"},
+		Codes:    map[int]string{1: "one"},
+		Backends: []*Backend{{Host: "a", Port: 1}},
+	}
+	a := &Anvil{Mode: SkipEmpty, Formatter: SeparatorFormatter{Separator: "/"}}
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Config
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestAnvil_Unflatten_WithSeparatorFormatter(t *testing.T) {
+	items := []Item{
+		{Key: "Config.Tags.1", Value: "two"},
+		{Key: "Config.Limits.cpu", Value: 2},
+		{Key: "Config.Tags.0", Value: "one"},
+	}
+	expected := map[string]interface{}{
+		"Tags":   []interface{}{"one", "two"},
+		"Limits": map[string]interface{}{"cpu": 2},
+	}
+
+	r, err := (&Anvil{Formatter: SeparatorFormatter{Separator: "."}}).Unflatten(items)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestSeparatorFormatter_Split_Errors(t *testing.T) {
+	if _, err := (SeparatorFormatter{}).Split("a"); err == nil {
+		t.Error("error expected for empty separator")
+	}
+	if _, err := (SeparatorFormatter{Separator: "/", Escaping: QuoteEscaping}).Split(`/"a/b`); err == nil {
+		t.Error("error expected for a key with unclosed quote")
+	}
+}
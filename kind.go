@@ -0,0 +1,32 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import "reflect"
+
+// KindPolicy of values without a notation: channels, functions and unsafe pointers
+type KindPolicy int
+
+const (
+	// KindError stops notation with an error
+	KindError KindPolicy = iota
+	// KindSkip values
+	KindSkip
+	// KindNil item with a nil value
+	KindNil
+	// KindType item with a type description as a value, e.g. `chan string`
+	KindType
+)
+
+// indirect value of pointers, invalid value for a nil pointer
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Worker struct {
+	Name    string
+	Handle  uintptr
+	Limit   **int
+	Backup  **Line
+	Jobs    chan string
+	Handler func() error
+}
+
+func TestAnvil_Notation_MultiLevelPointers(t *testing.T) {
+	limit := new(int)
+	*limit = 3
+	v := Worker{Name: "w", Handle: 0xff, Limit: &limit, Backup: new(*Line)}
+	a := &Anvil{Glue: ".", Kinds: map[reflect.Kind]KindPolicy{reflect.Chan: KindSkip, reflect.Func: KindSkip}}
+
+	r, err := a.Notation(&v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Worker.Name", Value: "w"},
+		{Key: "Worker.Handle", Value: uintptr(0xff)},
+		{Key: "Worker.Limit", Value: 3},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_WithKinds(t *testing.T) {
+	v := Worker{Jobs: make(chan string), Handler: nil}
+	cases := map[KindPolicy][]Item{
+		KindSkip: {
+			{Key: "Worker.Name", Value: ""},
+			{Key: "Worker.Handle", Value: uintptr(0)},
+		},
+		KindNil: {
+			{Key: "Worker.Name", Value: ""},
+			{Key: "Worker.Handle", Value: uintptr(0)},
+			{Key: "Worker.Jobs", Value: nil},
+			{Key: "Worker.Handler", Value: nil},
+		},
+		KindType: {
+			{Key: "Worker.Name", Value: ""},
+			{Key: "Worker.Handle", Value: uintptr(0)},
+			{Key: "Worker.Jobs", Value: "chan string"},
+			{Key: "Worker.Handler", Value: "func() error"},
+		},
+	}
+	for policy, expected := range cases {
+		a := &Anvil{Glue: ".", Kinds: map[reflect.Kind]KindPolicy{reflect.Chan: policy, reflect.Func: policy}}
+
+		r, err := a.Notation(v)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("policy %d: expected %#v, occurred %#v", policy, expected, r)
+		}
+	}
+}
+
+func TestAnvil_Notation_WithKinds_SkipEmpty(t *testing.T) {
+	v := Worker{Jobs: make(chan string)}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Kinds: map[reflect.Kind]KindPolicy{reflect.Chan: KindType, reflect.Func: KindType}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "Worker.Jobs", Value: "chan string"}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_WithKinds_Error(t *testing.T) {
+	cases := map[string]interface{}{
+		"chan": Worker{Jobs: make(chan string)},
+	}
+	for name, v := range cases {
+		_, err := (&Anvil{Glue: "."}).Notation(v)
+
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import "reflect"
+
+// Markers of nil and empty containers, values of items in NoSkipEmpty mode,
+// nil markers keep a default behaviour: nil values of containers and skipped nil pointers.
+// Unnotation compares values with markers, so markers must differ from each other
+// and from values of a notation, e.g. be values of own types
+type Markers struct {
+	// NilSlice value of a nil slice
+	NilSlice interface{}
+	// EmptySlice value of a non-nil slice without elements
+	EmptySlice interface{}
+	// NilMap value of a nil map
+	NilMap interface{}
+	// EmptyMap value of a non-nil map without entries
+	EmptyMap interface{}
+	// NilPointer value of a nil pointer, nil pointers are skipped if not set
+	NilPointer interface{}
+}
+
+// JSONMarkers of containers same as JSON literals,
+// values are strings of distinct unexported types never equal to notation values
+var JSONMarkers = Markers{
+	NilSlice:   sliceMarker("null"),
+	EmptySlice: sliceMarker("[]"),
+	NilMap:     mapMarker("null"),
+	EmptyMap:   mapMarker("{}"),
+	NilPointer: pointerMarker("null"),
+}
+
+// markers of JSONMarkers, a type tells a nil pointer from a nil slice or map
+// and any marker from a string value
+type (
+	sliceMarker   string
+	mapMarker     string
+	pointerMarker string
+)
+
+// marked value of a container kind for a marker value, false if value is not a marker
+func (m Markers) marked(v reflect.Value, value interface{}) (reflect.Value, bool) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if marker(value, m.NilPointer) {
+			return reflect.Zero(v.Type()), true
+		}
+	case reflect.Slice:
+		if marker(value, m.NilSlice) {
+			return reflect.Zero(v.Type()), true
+		}
+		if marker(value, m.EmptySlice) {
+			return reflect.MakeSlice(v.Type(), 0, 0), true
+		}
+	case reflect.Map:
+		if marker(value, m.NilMap) {
+			return reflect.Zero(v.Type()), true
+		}
+		if marker(value, m.EmptyMap) {
+			return reflect.MakeMap(v.Type()), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// marker value is equal to a set marker
+func marker(value, marker interface{}) bool {
+	return marker != nil && reflect.DeepEqual(value, marker)
+}
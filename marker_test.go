@@ -0,0 +1,195 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+type Shape struct {
+	NilSlice   []int
+	EmptySlice []int
+	NilMap     map[string]int
+	EmptyMap   map[string]int
+	NilPointer *Line
+	Pointer    *[]string
+	Nested     []map[string]int
+}
+
+func TestAnvil_Notation_WithMarkers(t *testing.T) {
+	v := Shape{
+		EmptySlice: []int{},
+		EmptyMap:   map[string]int{},
+		Pointer:    &[]string{},
+		Nested:     []map[string]int{nil, {}},
+	}
+	a := &Anvil{Glue: ".", Markers: JSONMarkers}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Shape.NilSlice", Value: sliceMarker("null")},
+		{Key: "Shape.EmptySlice", Value: sliceMarker("[]")},
+		{Key: "Shape.NilMap", Value: mapMarker("null")},
+		{Key: "Shape.EmptyMap", Value: mapMarker("{}")},
+		{Key: "Shape.NilPointer", Value: pointerMarker("null")},
+		{Key: "Shape.Pointer", Value: sliceMarker("[]")},
+		{Key: "Shape.Nested[0]", Value: mapMarker("null")},
+		{Key: "Shape.Nested[1]", Value: mapMarker("{}")},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+
+	var occurred Shape
+	if err = a.Unnotation(r, &occurred); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(v, occurred) {
+		t.Errorf("expected %#v, occurred %#v", v, occurred)
+	}
+}
+
+func TestAnvil_Unnotation_WithMarkers_Literals(t *testing.T) {
+	type Literals struct {
+		Text     *string
+		Texts    []string
+		Slice    *[]string
+		Map      *map[string]int
+		Pointers []*string
+	}
+	null, brackets := "null", "[]"
+	v := Literals{
+		Text:     &null,
+		Texts:    []string{"null", "[]", "{}"},
+		Slice:    new([]string),
+		Map:      new(map[string]int),
+		Pointers: []*string{&brackets, nil},
+	}
+	a := &Anvil{Glue: ".", Markers: JSONMarkers}
+	items, err := a.Notation(v)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Literals
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(v, occurred) {
+		t.Errorf("expected %#v, occurred %#v", v, occurred)
+	}
+}
+
+func TestAnvil_Notation_WithMarkers_FlattenEmbedded(t *testing.T) {
+	type (
+		Inner struct {
+			X int
+			P *int
+		}
+		Outer struct {
+			*Inner
+			Y   int
+			Ptr *int
+		}
+	)
+	v := Outer{Y: 1}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: ".", Markers: JSONMarkers, FlattenEmbedded: true}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	// promoted fields of a nil embedded pointer are skipped
+	expected := []Item{
+		{Key: "Outer.Y", Value: 1},
+		{Key: "Outer.Ptr", Value: pointerMarker("null")},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+
+	var occurred Outer
+	if err = a.Unnotation(r, &occurred); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(v, occurred) {
+		t.Errorf("expected %#v, occurred %#v", v, occurred)
+	}
+}
+
+func TestAnvil_Notation_WithMarkers_SkipEmpty(t *testing.T) {
+	v := Shape{EmptySlice: []int{}, EmptyMap: map[string]int{}}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Markers: JSONMarkers}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if len(r) > 0 {
+		t.Errorf("expected no items, occurred %#v", r)
+	}
+}
+
+func TestAnvil_Notation_WithNilPointerMarker(t *testing.T) {
+	r, err := (&Anvil{Glue: ".", Markers: Markers{NilPointer: "null"}}).Notation([]*int{nil})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{{Key: "[0]", Value: "null"}}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Notation_WithNilPointerElements(t *testing.T) {
+	one := 1
+	type Pointers struct {
+		List  []*int
+		Array [2]*int
+		Map   map[string]*int
+		Deep  **int
+	}
+	v := Pointers{
+		List:  []*int{nil, &one},
+		Array: [2]*int{&one, nil},
+		Map:   map[string]*int{"a": nil, "b": &one},
+		Deep:  new(*int),
+	}
+	// nil elements are skipped the same way as nil fields in any mode
+	expected := []Item{
+		{Key: "Pointers.List[1]", Value: 1},
+		{Key: "Pointers.Array[0]", Value: 1},
+		{Key: "Pointers.Map[b]", Value: 1},
+	}
+	for _, mode := range []Mode{SkipEmpty, NoSkipEmpty} {
+		r, err := (&Anvil{Mode: mode, Glue: "."}).Notation(v)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("%v: expected %#v, occurred %#v", mode, expected, r)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Items value of a modifier expanded to nested items of a modified value,
+// keys of items are names of nested fields, an empty key is a key of a modified value
+type Items []Item
+
+// Expand modifier of a value to nested items, e.g. `.value` and `.currency` of an amount,
+// registered same as other modifiers, a value without items is empty
+func Expand(fn func(f reflect.Value) ([]Item, error)) func(f reflect.Value) (interface{}, bool, error) {
+	return func(f reflect.Value) (interface{}, bool, error) {
+		items, err := fn(f)
+		return Items(items), len(items) < 1, err
+	}
+}
+
+// expand items of a modifier by a key of a modified value and a formatter, nil values are skipped if skip
+func expand(f KeyFormatter, key string, items Items, skip bool) []Item {
+	var expanded []Item
+	for _, item := range items {
+		if item.Value == nil && skip {
+			continue
+		}
+		if len(item.Key) > 0 {
+			item.Key = f.Field(key, item.Key)
+		} else {
+			item.Key = key
+		}
+		expanded = append(expanded, item)
+	}
+	return expanded
+}
+
+// implementer modifier of values implementing an interface
+type implementer struct {
+	iface reflect.Type
+	fn    modifierFunc
+}
+
+// RegisterInterfaceModifierFunc - assign a modifier function to values implementing an interface,
+// iface is a nil pointer to an interface e.g. `(*fmt.Stringer)(nil)`,
+// modifiers of an exact type take precedence, then interfaces in order of registration,
+// values implementing an interface by a pointer receiver are passed by address if addressable
+func (s *Anvil) RegisterInterfaceModifierFunc(iface interface{}, mod func(f reflect.Value) (interface{}, bool, error)) *Anvil {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return s.fail(fmt.Errorf("anvil:modifier of a pointer to an interface expected, occurred %T", iface))
+	}
+	s.implementers = append(s.implementers, implementer{iface: t.Elem(), fn: plain(mod)})
+	return s
+}
+
+// pathModifier modifier of values by a pattern of paths
+type pathModifier struct {
+	pattern  string
+	segments []Segment
+	fn       modifierFunc
+}
+
+// RegisterPathModifierFunc - assign a modifier function to values by a glob pattern of paths
+// same as Include patterns (`User.CreatedAt`, `Orders[*].Amount`), modifiers of paths take precedence
+// over modifiers of types and interfaces, a first registered matching pattern is used
+func (s *Anvil) RegisterPathModifierFunc(pattern string, mod func(f reflect.Value) (interface{}, bool, error)) *Anvil {
+	s.paths = append(s.paths, pathModifier{pattern: pattern, fn: plain(mod)})
+	return s
+}
+
+// compilePaths patterns of modifiers split by a Formatter
+func (s *Anvil) compilePaths() ([]pathModifier, error) {
+	if len(s.paths) < 1 {
+		return nil, nil
+	}
+	paths := make([]pathModifier, len(s.paths))
+	for i, m := range s.paths {
+		segments, err := s.Split(m.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("anvil:modifier pattern %q: %v", m.pattern, err)
+		}
+		paths[i] = pathModifier{pattern: m.pattern, segments: segments, fn: m.fn}
+	}
+	return paths, nil
+}
+
+// modifierOf a value, a modifier of a path, of an exact type or of a first implemented interface,
+// v is an address of a value for an interface implemented by a pointer receiver
+func (s *Anvil) modifierOf(v reflect.Value, path trail) (modifierFunc, reflect.Value, bool) {
+	if !v.IsValid() {
+		return nil, v, false
+	}
+	// interface values are modified by a path of a dynamic value
+	if v.Kind() != reflect.Interface {
+		for _, m := range path.modifiers {
+			if matched, _ := match(m.segments, path.segments); matched {
+				return m.fn, v, true
+			}
+		}
+	}
+	if fn, ok := s.modifier[v.Type()]; ok {
+		return fn, v, true
+	}
+	if name, ok := qualified(v.Type()); ok {
+		if t, ok := s.qualified[name]; ok {
+			return s.modifier[t], v, true
+		}
+	}
+	// interface values are modified by a dynamic type, methods of unexported values could not be called
+	if v.Kind() == reflect.Interface || !v.CanInterface() {
+		return nil, v, false
+	}
+	for _, m := range s.implementers {
+		if v.Type().Implements(m.iface) {
+			return m.fn, v, true
+		}
+		if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(m.iface) {
+			return m.fn, v.Addr(), true
+		}
+	}
+	return nil, v, false
+}
+
+// qualified name of a named type, `path/to/pkg.Name`
+func qualified(t reflect.Type) (string, bool) {
+	if len(t.Name()) < 1 || len(t.PkgPath()) < 1 {
+		return "", false
+	}
+	return t.PkgPath() + "." + t.Name(), true
+}
+
+// fail registration of a modifier, a first error is kept
+func (s *Anvil) fail(err error) *Anvil {
+	if s.err == nil {
+		s.err = err
+	}
+	return s
+}
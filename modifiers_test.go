@@ -0,0 +1,245 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	html "html/template"
+	"net"
+	"reflect"
+	"testing"
+	text "text/template"
+	"time"
+
+	"github.com/iveronanomi/anvil/modifier"
+)
+
+type Redactable interface {
+	Redact() string
+}
+
+type Password string
+
+func (p *Password) Redact() string {
+	return "***"
+}
+
+type Credentials struct {
+	Login    string
+	Password Password
+	Status   Status
+	Created  time.Time
+	Meta     interface{}
+}
+
+func TestAnvil_RegisterInterfaceModifierFunc(t *testing.T) {
+	v := &Credentials{
+		Login:    "root",
+		Password: "secret",
+		Status:   1,
+		Created:  time.Date(2019, 4, 22, 15, 49, 32, 0, time.UTC),
+		Meta:     Status(0),
+	}
+	a := &Anvil{Glue: "."}
+	a.RegisterModifierFunc(time.Time{}, modifier.Time).
+		RegisterInterfaceModifierFunc((*Redactable)(nil), func(v reflect.Value) (interface{}, bool, error) {
+			return v.Interface().(Redactable).Redact(), false, nil
+		}).
+		RegisterInterfaceModifierFunc((*fmt.Stringer)(nil), modifier.String)
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Credentials.Login", Value: "root"},
+		{Key: "Credentials.Password", Value: "***"},
+		{Key: "Credentials.Status", Value: "ACTIVE"},
+		{Key: "Credentials.Created", Value: "2019-04-22T15:49:32Z"},
+		{Key: "Credentials.Meta", Value: "INACTIVE"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_RegisterInterfaceModifierFunc_ExpectedError(t *testing.T) {
+	cases := map[string]interface{}{
+		"nil":       nil,
+		"interface": fmt.Stringer(Status(0)),
+		"pointer":   new(int),
+	}
+	for name, iface := range cases {
+		a := &Anvil{Glue: "."}
+		a.RegisterInterfaceModifierFunc(iface, modifier.String)
+
+		_, err := a.Notation(1)
+
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAnvil_RegisterModifierFunc_SameTypeNames(t *testing.T) {
+	type Templates struct {
+		Text text.Template
+		HTML html.Template
+	}
+	a := &Anvil{Glue: "."}
+	a.RegisterModifierFunc(text.Template{}, func(v reflect.Value) (interface{}, bool, error) {
+		return "text", false, nil
+	}).RegisterModifierFunc(html.Template{}, func(v reflect.Value) (interface{}, bool, error) {
+		return "html", false, nil
+	})
+
+	r, err := a.Notation(Templates{})
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Templates.Text", Value: "text"},
+		{Key: "Templates.HTML", Value: "html"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_RegisterModifierFunc_ExpectedError(t *testing.T) {
+	first := func() interface{} {
+		type ID int
+		return ID(0)
+	}
+	second := func() interface{} {
+		type ID int
+		return ID(0)
+	}
+	cases := map[string][]interface{}{
+		"nil":       {nil},
+		"ambiguous": {first(), second()},
+	}
+	for name, types := range cases {
+		a := &Anvil{Glue: "."}
+		for _, typ := range types {
+			a.RegisterModifierFunc(typ, modifier.String)
+		}
+
+		_, err := a.Notation(1)
+
+		if err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAnvil_RegisterPathModifierFunc(t *testing.T) {
+	type Payment struct {
+		Amount int
+	}
+	type Client struct {
+		Born     time.Time
+		Created  time.Time
+		Payments []Payment
+		Meta     interface{}
+	}
+	clock := time.Date(2019, 4, 22, 15, 49, 32, 0, time.UTC)
+	v := Client{Born: clock, Created: clock, Payments: []Payment{{Amount: 150}}, Meta: 7}
+	a := &Anvil{Glue: "."}
+	a.RegisterModifierFunc(time.Time{}, modifier.Time).
+		RegisterPathModifierFunc("Client.Born", func(v reflect.Value) (interface{}, bool, error) {
+			return v.Interface().(time.Time).Format("2006-01-02"), false, nil
+		}).
+		RegisterPathModifierFunc("Client.Payments[*].Amount", func(v reflect.Value) (interface{}, bool, error) {
+			return fmt.Sprintf("%.2f", float64(v.Int())/100), v.Int() == 0, nil
+		}).
+		RegisterPathModifierFunc("**.Meta", func(v reflect.Value) (interface{}, bool, error) {
+			return v.Kind().String(), false, nil
+		})
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Client.Born", Value: "2019-04-22"},
+		{Key: "Client.Created", Value: "2019-04-22T15:49:32Z"},
+		{Key: "Client.Payments[0].Amount", Value: "1.50"},
+		{Key: "Client.Meta", Value: "int"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_RegisterPathModifierFunc_ExpectedError(t *testing.T) {
+	a := &Anvil{Glue: "."}
+	a.RegisterPathModifierFunc("Client[0", modifier.String)
+
+	_, err := a.Notation(1)
+
+	if err == nil {
+		t.Error("expected error")
+	}
+}
+
+type Amount struct {
+	Cents    int64
+	Currency string
+}
+
+func TestAnvil_Notation_ExpandModifier(t *testing.T) {
+	type Invoice struct {
+		Total   Amount
+		Paid    Amount
+		Network net.IPNet
+	}
+	v := Invoice{
+		Total:   Amount{Cents: 1050, Currency: "EUR"},
+		Network: net.IPNet{IP: net.IPv4(10, 0, 0, 0), Mask: net.CIDRMask(8, 32)},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	a.RegisterModifierFunc(Amount{}, Expand(func(v reflect.Value) ([]Item, error) {
+		amount := v.Interface().(Amount)
+		if amount.Currency == "" {
+			return nil, nil
+		}
+		return []Item{
+			{Key: "value", Value: fmt.Sprintf("%d.%02d", amount.Cents/100, amount.Cents%100)},
+			{Key: "currency", Value: amount.Currency},
+			{Key: "note", Value: nil},
+		}, nil
+	})).RegisterModifierFunc(net.IPNet{}, Expand(func(v reflect.Value) ([]Item, error) {
+		network := v.Interface().(net.IPNet)
+		return []Item{
+			{Value: network.String()},
+			{Key: "ip", Value: network.IP.String()},
+			{Key: "mask", Value: net.IP(network.Mask).String()},
+		}, nil
+	}))
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "Invoice.Total.value", Value: "10.50"},
+		{Key: "Invoice.Total.currency", Value: "EUR"},
+		{Key: "Invoice.Network", Value: "10.0.0.0/8"},
+		{Key: "Invoice.Network.ip", Value: "10.0.0.0"},
+		{Key: "Invoice.Network.mask", Value: "255.0.0.0"},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"sort"
+)
+
+// entry of a map
+type entry struct {
+	key, value reflect.Value
+}
+
+// entries of a map value ordered by keys with MapLess, by natural ordering of keys if not set,
+// values are taken by iteration as keys like NaN could not be looked up
+func (s *Anvil) entries(v reflect.Value) []entry {
+	entries := make([]entry, 0, v.Len())
+	for it := v.MapRange(); it.Next(); {
+		entries = append(entries, entry{key: it.Key(), value: it.Value()})
+	}
+	less := s.MapLess
+	if less == nil {
+		less = func(a, b reflect.Value) bool {
+			return compare(a, b) < 0
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i].key, entries[j].key)
+	})
+	return entries
+}
+
+// compare values of the same type by natural ordering of a kind: -1, 0 or 1,
+// numbers by value with NaN first, false before true, strings lexically,
+// pointers and channels by address, structures and arrays element by element,
+// interfaces with nil first, then by a name of a dynamic type and by a value
+func compare(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return order(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return order(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	case reflect.String:
+		return order(a.String() < b.String(), a.String() > b.String())
+	case reflect.Float32, reflect.Float64:
+		return floats(a.Float(), b.Float())
+	case reflect.Complex64, reflect.Complex128:
+		if c := floats(real(a.Complex()), real(b.Complex())); c != 0 {
+			return c
+		}
+		return floats(imag(a.Complex()), imag(b.Complex()))
+	case reflect.Bool:
+		return order(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return order(a.Pointer() < b.Pointer(), a.Pointer() > b.Pointer())
+	case reflect.Struct, reflect.Array:
+		ae, be := elements(a), elements(b)
+		for i := range ae {
+			if c := compare(ae[i], be[i]); c != 0 {
+				return c
+			}
+		}
+	case reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			return order(a.IsNil() && !b.IsNil(), !a.IsNil() && b.IsNil())
+		}
+		at, bt := a.Elem().Type(), b.Elem().Type()
+		if at != bt {
+			return order(at.String() < bt.String(), at.String() > bt.String())
+		}
+		return compare(a.Elem(), b.Elem())
+	}
+	return 0
+}
+
+// floats ordering, NaN is less than any number
+func floats(a, b float64) int {
+	return order(a < b || a != a && b == b, a > b || b != b && a == a)
+}
+
+// order of a comparison result
+func order(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAnvil_Notation_Map_SortedKeys(t *testing.T) {
+	cases := map[string]struct {
+		v        interface{}
+		expected []string
+	}{
+		"strings": {
+			v:        map[string]int{"b": 1, "a": 2, "c": 3, "": 4},
+			expected: []string{"[]", "[a]", "[b]", "[c]"},
+		},
+		"ints": {
+			v:        map[int]int{10: 1, -2: 2, 3: 3},
+			expected: []string{"[-2]", "[3]", "[10]"},
+		},
+		"floats": {
+			v:        map[float64]int{2.5: 1, math.NaN(): 2, -1: 3},
+			expected: []string{"[NaN]", "[-1]", "[2.5]"},
+		},
+		"complex": {
+			v:        map[complex64]int{1 + 2i: 1, 1 + 1i: 2, 0: 3},
+			expected: []string{"[(0+0i)]", "[(1+1i)]", "[(1+2i)]"},
+		},
+		"arrays": {
+			v:        map[[2]int]int{{1, 2}: 1, {1, 1}: 2, {0, 9}: 3},
+			expected: []string{"[{0,9}]", "[{1,1}]", "[{1,2}]"},
+		},
+		"interfaces": {
+			v:        map[interface{}]int{"b": 1, 2: 2, nil: 3, 1: 4, "a": 5},
+			expected: []string{"[<nil>]", "[1]", "[2]", "[a]", "[b]"},
+		},
+	}
+	for name, c := range cases {
+		for i := 0; i < 10; i++ {
+			r, err := (&Anvil{OmitRoot: true}).Notation(c.v)
+
+			if err != nil {
+				t.Error(err)
+				t.FailNow()
+			}
+			var keys []string
+			for _, item := range r {
+				keys = append(keys, item.Key)
+			}
+			if !reflect.DeepEqual(c.expected, keys) {
+				t.Errorf("%s: expected %v, occurred %v", name, c.expected, keys)
+				break
+			}
+		}
+	}
+}
+
+func TestAnvil_Notation_Map_WithMapLess(t *testing.T) {
+	v := map[string]int{"a": 1, "bb": 2, "ccc": 3}
+	a := &Anvil{Glue: ".", OmitRoot: true, MapLess: func(a, b reflect.Value) bool {
+		return a.Len() > b.Len()
+	}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := []Item{
+		{Key: "[ccc]", Value: 3},
+		{Key: "[bb]", Value: 2},
+		{Key: "[a]", Value: 1},
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
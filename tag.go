@@ -0,0 +1,236 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Tag of a structure field, parsed from a first found tag of Anvil.Tags
+type Tag struct {
+	// Name of a field in keys, a name of field if a tag name is empty
+	Name string
+	// Tagged name of a field, taken from a tag
+	Tagged bool
+	// Skip field `-` entirely
+	Skip bool
+	// OmitEmpty values of a field and its nested values regardless of Mode
+	OmitEmpty bool
+	// Inline nested values of a field (`inline` or `squash`) into a parent key
+	Inline bool
+	// String representation of a scalar value
+	String bool
+}
+
+// UnexportedPolicy of unexported structure fields
+type UnexportedPolicy int
+
+const (
+	// UnexportedInclude fields as exported ones
+	UnexportedInclude UnexportedPolicy = iota
+	// UnexportedSkip fields, fields of embedded structures are promoted regardless
+	UnexportedSkip
+	// UnexportedTagged fields are included only with a tag of Anvil.Tags
+	UnexportedTagged
+)
+
+// defaultTags of structure fields
+var defaultTags = []string{"json"}
+
+// tags consulted in order, `json` by default
+func (s *Anvil) tags() []string {
+	if len(s.Tags) > 0 {
+		return s.Tags
+	}
+	return defaultTags
+}
+
+// FieldTag of a structure field, tags are consulted in order of Anvil.Tags
+// and empty tags are skipped. Same as encoding/json does, `-` skips a field,
+// `-,` names a field `-` and an empty name falls back to a name of field,
+// tags of unexported fields are applied the same way
+func (s *Anvil) FieldTag(v reflect.StructField) Tag {
+	tag := Tag{Name: v.Name}
+	for _, key := range s.tags() {
+		value, ok := v.Tag.Lookup(key)
+		if !ok || len(value) < 1 {
+			continue
+		}
+		if value == "-" {
+			tag.Skip = true
+			return tag
+		}
+		options := strings.Split(value, ",")
+		if len(options[0]) > 0 {
+			tag.Name, tag.Tagged = options[0], true
+		}
+		for _, option := range options[1:] {
+			switch option {
+			case "omitempty":
+				tag.OmitEmpty = true
+			case "inline", "squash":
+				tag.Inline = true
+			case "string":
+				tag.String = true
+			}
+		}
+		return tag
+	}
+	return tag
+}
+
+// field of a structure type visible in keys
+type field struct {
+	// index sequence of a field, longer than one for promoted fields
+	index []int
+	tag   Tag
+}
+
+// fieldsCache of structure types, reflect.Type => []resolved by options
+var fieldsCache sync.Map
+
+// resolved fields of a structure type by options of Anvil
+type resolved struct {
+	tags       []string
+	flatten    bool
+	unexported UnexportedPolicy
+	fields     []field
+}
+
+// fields of a structure type visible in keys, cached by a type and options,
+// a returned slice is shared and must not be modified
+func (s *Anvil) fields(t reflect.Type) []field {
+	var cached []resolved
+	if c, ok := fieldsCache.Load(t); ok {
+		cached = c.([]resolved)
+		for i := range cached {
+			if s.resolves(cached[i]) {
+				return cached[i].fields
+			}
+		}
+	}
+	fields := s.resolve(t)
+	// a copy of a list is stored, concurrent lists of the same type are resolved again at most
+	r := resolved{
+		tags:       append([]string(nil), s.tags()...),
+		flatten:    s.FlattenEmbedded,
+		unexported: s.Unexported,
+		fields:     fields,
+	}
+	fieldsCache.Store(t, append(cached[:len(cached):len(cached)], r))
+	return fields
+}
+
+// resolves options of Anvil same as fields were resolved with
+func (s *Anvil) resolves(r resolved) bool {
+	tags := s.tags()
+	if r.flatten != s.FlattenEmbedded || r.unexported != s.Unexported || len(r.tags) != len(tags) {
+		return false
+	}
+	for i := range tags {
+		if r.tags[i] != tags[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// resolve fields of a structure type visible in keys in order of declaration,
+// fields of embedded structures without a tag name are promoted if FlattenEmbedded is set,
+// shadowed names are resolved as encoding/json does: the shallowest field wins,
+// then the only tagged one, fields with the same name are dropped otherwise
+func (s *Anvil) resolve(t reflect.Type) []field {
+	fields := s.walk(t, nil, map[reflect.Type]bool{t: true})
+	if !s.FlattenEmbedded {
+		return fields
+	}
+	// candidates of names with the shallowest depth
+	candidates := make(map[string][]int, len(fields))
+	for i := range fields {
+		c := candidates[fields[i].tag.Name]
+		if len(c) > 0 && len(fields[c[0]].index) < len(fields[i].index) {
+			continue
+		}
+		if len(c) > 0 && len(fields[c[0]].index) > len(fields[i].index) {
+			c = nil
+		}
+		candidates[fields[i].tag.Name] = append(c, i)
+	}
+	var visible []field
+	for i := range fields {
+		if dominant(fields, candidates[fields[i].tag.Name]) == i {
+			visible = append(visible, fields[i])
+		}
+	}
+	return visible
+}
+
+// dominant field of candidates with the same name and depth, -1 if ambiguous
+func dominant(fields []field, candidates []int) int {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+	tagged := -1
+	for _, i := range candidates {
+		if !fields[i].tag.Tagged {
+			continue
+		}
+		if tagged > -1 {
+			return -1
+		}
+		tagged = i
+	}
+	return tagged
+}
+
+// walk fields of a structure type by index prefix, seen types of embedded structures are not walked twice
+func (s *Anvil) walk(t reflect.Type, prefix []int, seen map[reflect.Type]bool) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := s.FieldTag(f)
+		if tag.Skip {
+			continue
+		}
+		index := append(append([]int(nil), prefix...), i)
+		if s.FlattenEmbedded && f.Anonymous && !tag.Tagged {
+			e := f.Type
+			if e.Kind() == reflect.Ptr {
+				e = e.Elem()
+			}
+			if e.Kind() == reflect.Struct {
+				if !seen[e] {
+					seen[e] = true
+					fields = append(fields, s.walk(e, index, seen)...)
+					delete(seen, e)
+				}
+				continue
+			}
+		}
+		if len(f.PkgPath) > 0 && !s.unexported(f) {
+			continue
+		}
+		fields = append(fields, field{index: index, tag: tag})
+	}
+	return fields
+}
+
+// unexported field is visible by Unexported policy
+func (s *Anvil) unexported(f reflect.StructField) bool {
+	switch s.Unexported {
+	case UnexportedSkip:
+		return false
+	case UnexportedTagged:
+		for _, key := range s.tags() {
+			if value, ok := f.Tag.Lookup(key); ok && len(value) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
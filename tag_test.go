@@ -0,0 +1,263 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+type (
+	Tagged struct {
+		Name    string            `yaml:"name" json:"title"`
+		Port    int               `json:"port,string"`
+		Debug   bool              `json:"debug,omitempty"`
+		Secret  string            `json:"-"`
+		Dash    string            `json:"-,"`
+		Empty   string            `json:",omitempty"`
+		Limits  Limits            `mapstructure:",squash"`
+		Options *Options          `yaml:",inline"`
+		Labels  map[string]string `json:",inline"`
+	}
+	Limits struct {
+		MaxItems int `json:"max_items"`
+	}
+	Options struct {
+		Verbose bool `yaml:"verbose"`
+	}
+)
+
+func TestAnvil_FieldTag(t *testing.T) {
+	a := &Anvil{Tags: []string{"yaml", "mapstructure", "json"}}
+	typ := reflect.TypeOf(Tagged{})
+	cases := map[string]Tag{
+		"Name":    {Name: "name", Tagged: true},
+		"Port":    {Name: "port", Tagged: true, String: true},
+		"Debug":   {Name: "debug", Tagged: true, OmitEmpty: true},
+		"Secret":  {Name: "Secret", Skip: true},
+		"Dash":    {Name: "-", Tagged: true},
+		"Empty":   {Name: "Empty", OmitEmpty: true},
+		"Limits":  {Name: "Limits", Inline: true},
+		"Options": {Name: "Options", Inline: true},
+		"Labels":  {Name: "Labels", Inline: true},
+	}
+	for name, expected := range cases {
+		f, _ := typ.FieldByName(name)
+
+		r := a.FieldTag(f)
+
+		if r != expected {
+			t.Errorf("%s: expected %#v, occurred %#v", name, expected, r)
+		}
+	}
+}
+
+func TestAnvil_Notation_WithTags(t *testing.T) {
+	v := Tagged{
+		Name:    "service",
+		Port:    80,
+		Secret:  "secret",
+		Dash:    "dash",
+		Limits:  Limits{MaxItems: 10},
+		Options: &Options{Verbose: true},
+		Labels:  map[string]string{"env": "prod"},
+	}
+	expected := []Item{
+		{Key: "Tagged.name", Value: "service"},
+		{Key: "Tagged.port", Value: "80"},
+		{Key: "Tagged.-", Value: "dash"},
+		{Key: "Tagged.max_items", Value: 10},
+		{Key: "Tagged.verbose", Value: true},
+		{Key: "Tagged[env]", Value: "prod"},
+	}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: ".", Tags: []string{"yaml", "mapstructure", "json"}}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Unnotation_WithTags(t *testing.T) {
+	expected := Tagged{
+		Name:    "service",
+		Port:    80,
+		Debug:   true,
+		Dash:    "dash",
+		Limits:  Limits{MaxItems: 10},
+		Options: &Options{Verbose: true},
+		Labels:  map[string]string{"env": "prod"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", Tags: []string{"yaml", "mapstructure", "json"}}
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Tagged
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+type (
+	Entity struct {
+		ID   int
+		Name string
+	}
+	Named struct {
+		Name string `json:"Name"`
+	}
+	Stamp struct {
+		Created int
+	}
+	Labeled struct {
+		Label string
+	}
+	Document struct {
+		Entity
+		*Stamp
+		Named
+		Labeled `json:"labeled"`
+		ID      string
+	}
+	Ident struct {
+		ID int
+	}
+)
+
+func TestAnvil_Notation_WithFlattenEmbedded(t *testing.T) {
+	v := Document{
+		Entity:  Entity{ID: 1, Name: "entity"},
+		Stamp:   &Stamp{Created: 2},
+		Named:   Named{Name: "named"},
+		Labeled: Labeled{Label: "label"},
+		ID:      "doc",
+	}
+	expected := []Item{
+		{Key: "Document.Created", Value: 2},
+		{Key: "Document.Name", Value: "named"},
+		{Key: "Document.labeled.Label", Value: "label"},
+		{Key: "Document.ID", Value: "doc"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", FlattenEmbedded: true}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+
+	var occurred Document
+	if err = a.Unnotation(r, &occurred); err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	v.Entity = Entity{}
+	if !reflect.DeepEqual(v, occurred) {
+		t.Errorf("expected %#v, occurred %#v", v, occurred)
+	}
+}
+
+func TestAnvil_Notation_WithFlattenEmbedded_AmbiguousNames(t *testing.T) {
+	type Twice struct {
+		Entity
+		Named
+		Ident
+	}
+	v := Twice{Entity: Entity{ID: 1, Name: "entity"}, Named: Named{Name: "named"}, Ident: Ident{ID: 2}}
+	expected := []Item{
+		{Key: "Twice.Name", Value: "named"},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", FlattenEmbedded: true}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithFlattenEmbedded_NilPointer(t *testing.T) {
+	v := Document{ID: "doc"}
+	expected := []Item{{Key: "Document.ID", Value: "doc"}}
+	a := &Anvil{Mode: SkipEmpty, Glue: ".", FlattenEmbedded: true}
+
+	r, err := a.Notation(v)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	check(t, expected, r)
+}
+
+func TestAnvil_Notation_WithUnexportedPolicy(t *testing.T) {
+	type (
+		secret struct {
+			Visible string
+			hidden  string
+		}
+		Session struct {
+			secret
+			Token string
+			user  string `anvil:"user"`
+			key   string
+		}
+	)
+	v := Session{secret: secret{Visible: "v", hidden: "h"}, Token: "t", user: "u", key: "k"}
+	cases := []struct {
+		anvil    *Anvil
+		expected []Item
+	}{
+		{
+			anvil: &Anvil{Mode: SkipEmpty, Glue: "."},
+			expected: []Item{
+				{Key: "Session.secret.Visible", Value: "v"},
+				{Key: "Session.secret.hidden", Value: "h"},
+				{Key: "Session.Token", Value: "t"},
+				{Key: "Session.user", Value: "u"},
+				{Key: "Session.key", Value: "k"},
+			},
+		},
+		{
+			anvil: &Anvil{Mode: SkipEmpty, Glue: ".", Unexported: UnexportedSkip, FlattenEmbedded: true},
+			expected: []Item{
+				{Key: "Session.Visible", Value: "v"},
+				{Key: "Session.Token", Value: "t"},
+			},
+		},
+		{
+			anvil: &Anvil{Mode: SkipEmpty, Glue: ".", Unexported: UnexportedTagged, Tags: []string{"anvil", "json"}},
+			expected: []Item{
+				{Key: "Session.Token", Value: "t"},
+				{Key: "Session.user", Value: "u"},
+			},
+		},
+	}
+	for _, c := range cases {
+		r, err := c.anvil.Notation(v)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		check(t, c.expected, r)
+	}
+}
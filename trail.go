@@ -0,0 +1,64 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import "reflect"
+
+// trail of a path to a value
+type trail struct {
+	// visited containers on a path with keys of them
+	visited map[visit]string
+	// depth of nesting
+	depth int
+	// segments of a path, kept for filters and modifiers of paths only
+	segments []Segment
+	// keep segments of a path
+	keep bool
+	// included path by Include patterns
+	included bool
+	// filter of paths, nil without Include and Exclude patterns
+	filter *filter
+	// modifiers of paths, nil without registered patterns
+	modifiers []pathModifier
+	// parent container of a value, invalid for a root value
+	parent reflect.Value
+	// index of a field of a parent structure, nil for elements
+	index []int
+	// format of keys, a formatter built once per walk
+	format KeyFormatter
+}
+
+// trail of a walk started by a key
+func (s *Anvil) trail(key string) (trail, error) {
+	t := trail{visited: make(map[visit]string), included: true, format: s.formatter()}
+	var err error
+	if t.modifiers, err = s.compilePaths(); err != nil {
+		return t, err
+	}
+	if len(s.Include) > 0 || len(s.Exclude) > 0 {
+		if t.filter, err = s.compile(); err != nil {
+			return t, err
+		}
+		t.included = len(t.filter.include) < 1
+	}
+	t.keep = t.filter != nil || len(t.modifiers) > 0
+	if t.keep && len(key) > 0 {
+		if t.segments, err = s.Split(key); err != nil {
+			t.segments = []Segment{{Name: key}}
+		}
+	}
+	return t, nil
+}
+
+// nested trail of a value one level deeper by a segment of a parent container
+func (t trail) nested(segment Segment, parent reflect.Value) trail {
+	t.depth++
+	t.parent, t.index = parent, nil
+	if t.keep {
+		// trails of siblings are walked one by one, a tail of segments is reused
+		t.segments = append(t.segments, segment)
+	}
+	return t
+}
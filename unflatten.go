@@ -0,0 +1,190 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Unflatten a list of []Item into a tree of map[string]interface{}
+// for struct fields and map keys, and []interface{} for dense `[N]` indexes from 0
+func Unflatten(items []Item, glue string) (interface{}, error) {
+	return (&Anvil{Glue: glue}).Unflatten(items)
+}
+
+// Unflatten a list of []Item into a tree of map[string]interface{}
+// for struct fields and map keys, and []interface{} for dense `[N]` indexes from 0,
+// bracket segments of a node with other keys are keys of a map.
+// Root prefix of keys is not a part of the tree, it must be the same for all items,
+// a type name is guessed by items if neither Root nor OmitRoot is set
+func (s *Anvil) Unflatten(items []Item) (interface{}, error) {
+	var tree interface{}
+	root := s.Root
+	if len(root) < 1 && !s.OmitRoot {
+		root = s.guessRoots(items)
+	}
+	for i := range items {
+		if !s.rooted(items[i].Key, root) {
+			return nil, fmt.Errorf("anvil:key %q does not belong to %q", items[i].Key, root)
+		}
+		segments, err := s.Split(items[i].Key[len(root):])
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = s.unflatten(tree, segments, items[i].Value); err != nil {
+			return nil, fmt.Errorf("anvil:%s: %v", items[i].Key, err)
+		}
+	}
+	return compact(tree), nil
+}
+
+// guessRoots of keys, a type name guessed by a first key if shared by all keys,
+// keys are root-less if a type name is not shared and a key has no segments after a name,
+// e.g. keys `Name` and `Tags[0]` of an anonymous structure
+func (s *Anvil) guessRoots(items []Item) string {
+	if len(items) < 1 {
+		return ""
+	}
+	root := s.guessRoot(items[0].Key)
+	for i := range items {
+		if !s.belongs(items[i].Key, root) {
+			for j := range items {
+				if s.guessRoot(items[j].Key) == items[j].Key {
+					return ""
+				}
+			}
+			return root
+		}
+	}
+	return root
+}
+
+// rooted key by a root prefix followed by a glue, a bracket or nothing
+func (s *Anvil) rooted(key, root string) bool {
+	if !strings.HasPrefix(key, root) {
+		return false
+	}
+	rest := key[len(root):]
+	glue := s.formatter().Field(root, "")[len(root):]
+	return len(root) < 1 || len(rest) < 1 || rest[0] == '[' || strings.HasPrefix(rest, glue)
+}
+
+// belongs key to a root prefix followed by segments of a formatter
+func (s *Anvil) belongs(key, root string) bool {
+	if !strings.HasPrefix(key, root) {
+		return false
+	}
+	segments, err := s.Split(key[len(root):])
+	return err == nil && s.join(root, segments) == key
+}
+
+// guessRoot of notation key, the shortest type name identifier
+// at the beginning of a key, followed by segments of a formatter
+func (s *Anvil) guessRoot(key string) string {
+	for i, r := range key {
+		identifier := r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+		if i > 0 || !identifier {
+			segments, err := s.Split(key[i:])
+			if err == nil && s.join(key[:i], segments) == key {
+				return key[:i]
+			}
+		}
+		if !identifier {
+			break
+		}
+	}
+	return key
+}
+
+// join segments to a prefix by a formatter
+func (s *Anvil) join(prefix string, segments []Segment) string {
+	f := s.formatter()
+	for _, segment := range segments {
+		if segment.Bracket {
+			prefix = f.MapKey(prefix, segment.Name)
+			continue
+		}
+		prefix = f.Field(prefix, segment.Name)
+	}
+	return prefix
+}
+
+type (
+	// fields node of a tree keeping elements by named segments, compacted to a map
+	fields map[string]interface{}
+	// indexes node of a tree keeping elements by bracket segments,
+	// compacted to a list if keys are dense indexes from 0 and to a map otherwise
+	indexes map[string]interface{}
+)
+
+// unflatten value into a node of tree by key segments,
+// maps and lists of a tree are reverted to fields and indexes nodes on the way
+func (s *Anvil) unflatten(node interface{}, segments []Segment, value interface{}) (interface{}, error) {
+	if len(segments) < 1 {
+		switch node.(type) {
+		case fields, indexes, map[string]interface{}, []interface{}:
+			return nil, fmt.Errorf("value conflicts with a nested %T", compact(node))
+		}
+		return value, nil
+	}
+	name := segments[0].Name
+	var dict map[string]interface{}
+	switch n := node.(type) {
+	case nil:
+		dict = make(map[string]interface{})
+	case fields:
+		dict = n
+	case indexes:
+		dict = n
+	case map[string]interface{}:
+		dict = n
+	case []interface{}:
+		dict = make(map[string]interface{}, len(n))
+		for i := range n {
+			dict[strconv.Itoa(i)] = n[i]
+		}
+	default:
+		return nil, fmt.Errorf("key %q conflicts with %T", name, node)
+	}
+	// a named segment turns a node to fields for good
+	if _, ok := node.(fields); ok || !segments[0].Bracket {
+		node = fields(dict)
+	} else {
+		node = indexes(dict)
+	}
+	var err error
+	if dict[name], err = s.unflatten(dict[name], segments[1:], value); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// compact fields and indexes nodes of a tree to maps and lists
+func compact(node interface{}) interface{} {
+	switch n := node.(type) {
+	case fields:
+		for key := range n {
+			n[key] = compact(n[key])
+		}
+		return map[string]interface{}(n)
+	case indexes:
+		for key := range n {
+			n[key] = compact(n[key])
+		}
+		list := make([]interface{}, len(n))
+		for i := range list {
+			e, ok := n[strconv.Itoa(i)]
+			if !ok {
+				return map[string]interface{}(n)
+			}
+			list[i] = e
+		}
+		return list
+	}
+	return node
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnflatten(t *testing.T) {
+	v := Config{
+		Name:     "service",
+		Tags:     []string{"one", "two"},
+		Limits:   map[string]int64{"cpu": 2},
+		Backends: []*Backend{{Host: "a", Port: 1}},
+	}
+	expected := map[string]interface{}{
+		"name":   "service",
+		"Tags":   []interface{}{"one", "two"},
+		"Limits": map[string]interface{}{"cpu": int64(2)},
+		"Backends": []interface{}{
+			map[string]interface{}{"Host": "a", "Port": 1},
+		},
+	}
+	items, err := Notation(v, SkipEmpty, "__")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	r, err := Unflatten(items, "__")
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestUnflatten_WithSliceRoot(t *testing.T) {
+	items := []Item{
+		{Key: "List[2].name", Value: "c"},
+		{Key: "List[0].name", Value: "a"},
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "a"},
+		nil,
+		map[string]interface{}{"name": "c"},
+	}
+	items = append(items, Item{Key: "List[1]", Value: nil})
+
+	r, err := Unflatten(items, ".")
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Unflatten_WithRoot(t *testing.T) {
+	cases := []struct {
+		anvil *Anvil
+		items []Item
+	}{
+		{anvil: &Anvil{Glue: ".", Root: "app"}, items: []Item{{Key: "app.db.host", Value: "a"}}},
+		{anvil: &Anvil{Glue: ".", OmitRoot: true}, items: []Item{{Key: "db.host", Value: "a"}}},
+	}
+	expected := map[string]interface{}{
+		"db": map[string]interface{}{"host": "a"},
+	}
+	for _, c := range cases {
+		r, err := c.anvil.Unflatten(c.items)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("expected %#v, occurred %#v", expected, r)
+		}
+	}
+}
+
+func TestUnflatten_AnonymousStruct(t *testing.T) {
+	v := struct {
+		Tags []string
+		Name string
+	}{Tags: []string{"a"}, Name: "n"}
+	items, err := Notation(v, NoSkipEmpty, ".")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	expected := map[string]interface{}{"Tags": []interface{}{"a"}, "Name": "n"}
+	for _, items := range [][]Item{items, {items[1], items[0]}} {
+		r, err := Unflatten(items, ".")
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("expected %#v, occurred %#v", expected, r)
+		}
+	}
+}
+
+func TestUnflatten_Errors(t *testing.T) {
+	cases := map[string][]Item{
+		"different roots": {{Key: "A.b", Value: 1}, {Key: "B.b", Value: 1}},
+		"value and key":   {{Key: "A.b.c", Value: 1}, {Key: "A.b", Value: 1}},
+		"key and value":   {{Key: "A.b", Value: 1}, {Key: "A.b.c", Value: 1}},
+	}
+	for name, items := range cases {
+		if _, err := Unflatten(items, "."); err == nil {
+			t.Errorf("%s: error expected", name)
+		}
+	}
+	if _, err := (&Anvil{Glue: ".", Root: "app"}).Unflatten([]Item{{Key: "apphost", Value: 1}}); err == nil {
+		t.Error("root of a name: error expected")
+	}
+}
+
+func TestUnflatten_WithNumericMapKeys(t *testing.T) {
+	type Numbers struct {
+		Signed map[int]string
+		Sparse map[int]string
+		Huge   map[int]string
+		Dense  map[int]string
+		Mixed  map[string]int
+	}
+	v := Numbers{
+		Signed: map[int]string{-1: "a", 2: "b"},
+		Sparse: map[int]string{1: "a", 3: "b"},
+		Huge:   map[int]string{5000000: "x"},
+		Dense:  map[int]string{1: "b", 0: "a"},
+		Mixed:  map[string]int{"1": 1, "x": 2},
+	}
+	expected := map[string]interface{}{
+		"Signed": map[string]interface{}{"-1": "a", "2": "b"},
+		"Sparse": map[string]interface{}{"1": "a", "3": "b"},
+		"Huge":   map[string]interface{}{"5000000": "x"},
+		"Dense":  []interface{}{"a", "b"},
+		"Mixed":  map[string]interface{}{"1": 1, "x": 2},
+	}
+	cases := map[string]*Anvil{
+		"brackets":  {Glue: "."},
+		"separator": {Formatter: SeparatorFormatter{Separator: "."}},
+	}
+	for name, a := range cases {
+		items, err := a.Notation(v)
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+
+		r, err := a.Unflatten(items)
+
+		if err != nil {
+			t.Error(err)
+			t.FailNow()
+		}
+		if !reflect.DeepEqual(expected, r) {
+			t.Errorf("%s: expected %#v, occurred %#v", name, expected, r)
+		}
+	}
+}
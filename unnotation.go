@@ -0,0 +1,358 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxIndex of slices grown by keys of items,
+// larger indexes are errors to not allocate a memory by a single key
+const DefaultMaxIndex = 1 << 20
+
+// Segment of a notation key
+type Segment struct {
+	// Name of a field, index of an array/slice or a key of a map
+	Name string
+	// Bracket segment `[Name]` used for arrays, slices and maps
+	Bracket bool
+}
+
+// Unnotation of a list of []Item into a go type instance,
+// target must be a non-nil pointer, keys are expected in a format
+// produced by Notation with the same Glue and Formatter
+func (s *Anvil) Unnotation(items []Item, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("anvil:target must be a non-nil pointer")
+	}
+	root := s.root(v.Type())
+	for i := range items {
+		if !s.rooted(items[i].Key, root) {
+			return fmt.Errorf("anvil:key %q does not belong to %q", items[i].Key, root)
+		}
+		segments, err := s.Split(items[i].Key[len(root):])
+		if err != nil {
+			return err
+		}
+		if err = s.unnotation(v.Elem(), segments, items[i].Value); err != nil {
+			return fmt.Errorf("anvil:%s: %v", items[i].Key, err)
+		}
+	}
+	return nil
+}
+
+// Assign value to a target pointer by key segments,
+// same as Unnotation does for a single item
+func (s *Anvil) Assign(target interface{}, segments []Segment, value interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return errors.New("anvil:target must be a non-nil pointer")
+	}
+	return s.unnotation(v.Elem(), segments, value)
+}
+
+// unnotation set value to the field found by key segments
+func (s *Anvil) unnotation(v reflect.Value, segments []Segment, value interface{}) error {
+	if len(segments) < 1 {
+		if m, ok := s.Markers.marked(v, value); ok {
+			v.Set(m)
+			return nil
+		}
+	}
+	// allocate nested pointers
+	for v.Kind() == reflect.Ptr {
+		if len(segments) < 1 && value == nil {
+			break
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if len(segments) < 1 {
+		if m, ok := s.Markers.marked(v, value); ok {
+			v.Set(m)
+			return nil
+		}
+		return assign(v, value)
+	}
+	name := segments[0].Name
+	switch v.Kind() {
+	case reflect.Struct:
+		index, inline := s.field(v.Type(), name)
+		if index == nil {
+			return fmt.Errorf("field %q not found in %s", name, v.Type())
+		}
+		for i, idx := range index {
+			// allocate pointers of inlined structures
+			for ; i > 0 && v.Kind() == reflect.Ptr; v = v.Elem() {
+				if v.IsNil() {
+					v.Set(reflect.New(v.Type().Elem()))
+				}
+			}
+			v = v.Field(idx)
+			// unexported fields could not be set
+			if !v.CanSet() {
+				return nil
+			}
+		}
+		if inline {
+			// segment is a key of an inlined map
+			return s.unnotation(v, segments, value)
+		}
+		return s.unnotation(v, segments[1:], value)
+	case reflect.Slice:
+		idx, err := strconv.Atoi(name)
+		if err != nil || idx < 0 || idx > s.maxIndex() {
+			return fmt.Errorf("invalid index %q of %s", name, v.Type())
+		}
+		if idx >= v.Len() {
+			v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), idx+1-v.Len(), idx+1-v.Len())))
+		}
+		return s.unnotation(v.Index(idx), segments[1:], value)
+	case reflect.Array:
+		idx, err := strconv.Atoi(name)
+		if err != nil || idx < 0 || idx >= v.Len() {
+			return fmt.Errorf("invalid index %q of %s", name, v.Type())
+		}
+		return s.unnotation(v.Index(idx), segments[1:], value)
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		if err := parse(key, name); err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		// map elements are not addressable, modify a copy
+		elem := reflect.New(v.Type().Elem()).Elem()
+		if e := v.MapIndex(key); e.IsValid() {
+			elem.Set(e)
+		}
+		if err := s.unnotation(elem, segments[1:], value); err != nil {
+			return err
+		}
+		v.SetMapIndex(key, elem)
+		return nil
+	case reflect.Interface:
+		// a pointer kept by interface is populated
+		if !v.IsNil() && v.Elem().Kind() == reflect.Ptr {
+			return s.unnotation(v.Elem(), segments, value)
+		}
+		// an empty interface keeps a tree same as Unflatten does
+		if v.NumMethod() < 1 {
+			tree, err := s.unflatten(v.Interface(), segments, value)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(compact(tree)))
+			return nil
+		}
+	}
+	return fmt.Errorf("unexpected segment %q for %s", name, v.Type())
+}
+
+// field index sequence of a structure type by a name in keys,
+// fields of inlined structures are looked up recursively,
+// inline flag is set for an inlined map, keeping any unknown name
+func (s *Anvil) field(t reflect.Type, name string) (index []int, inline bool) {
+	var maps []int
+	for _, field := range s.fields(t) {
+		if !field.tag.Inline {
+			if field.tag.Name == name {
+				return field.index, false
+			}
+			continue
+		}
+		f := t.FieldByIndex(field.index).Type
+		for f.Kind() == reflect.Ptr {
+			f = f.Elem()
+		}
+		switch f.Kind() {
+		case reflect.Struct:
+			if idx, inline := s.field(f, name); idx != nil {
+				return append(append([]int(nil), field.index...), idx...), inline
+			}
+		case reflect.Map:
+			if maps == nil {
+				maps = field.index
+			}
+		}
+	}
+	return maps, maps != nil
+}
+
+// maxIndex of slices grown by keys, MaxIndex or DefaultMaxIndex
+func (s *Anvil) maxIndex() int {
+	if s.MaxIndex > 0 {
+		return s.MaxIndex
+	}
+	return DefaultMaxIndex
+}
+
+// Split notation key (without a type name prefix) to a list of segments
+func (s *Anvil) Split(key string) ([]Segment, error) {
+	return s.formatter().Split(key)
+}
+
+// assign value to a field, string values are parsed for a scalar fields
+func assign(v reflect.Value, value interface{}) error {
+	if value == nil {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	val := reflect.ValueOf(value)
+	if val.Type().AssignableTo(v.Type()) {
+		v.Set(val)
+		return nil
+	}
+	if str, ok := value.(string); ok {
+		return parse(v, str)
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		if val.Type().ConvertibleTo(v.Type()) && val.Kind() != reflect.String {
+			v.Set(val.Convert(v.Type()))
+			return nil
+		}
+	case reflect.Bool:
+		if val.Kind() == reflect.Bool {
+			v.SetBool(val.Bool())
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot assign %s to %s", val.Type(), v.Type())
+}
+
+// parse string representation of a value into v
+func parse(v reflect.Value, str string) error {
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(str))
+		}
+	}
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(str)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(str, 10, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		if n, err = strconv.ParseUint(str, 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		if n, err = strconv.ParseFloat(str, v.Type().Bits()); err == nil {
+			v.SetFloat(n)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(str); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Complex64, reflect.Complex128:
+		// complex keys are printed as `(1+2i)` by fmt, scanned back the same way
+		// as strconv.ParseComplex is not available before go1.15
+		var c complex128
+		r := strings.NewReader(str)
+		if _, err = fmt.Fscan(r, &c); err != nil || r.Len() > 0 || v.OverflowComplex(c) {
+			return fmt.Errorf("cannot parse %q as %s", str, v.Type())
+		}
+		v.SetComplex(c)
+	case reflect.Interface:
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("cannot assign string to %s", v.Type())
+		}
+		// texts of nil and quoted strings of interface keys
+		if str == nilKey {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if strings.HasPrefix(str, `"`) {
+			unquoted, err := strconv.Unquote(str)
+			if err != nil {
+				return fmt.Errorf("cannot parse %q as %s", str, v.Type())
+			}
+			str = unquoted
+		}
+		v.Set(reflect.ValueOf(str))
+	case reflect.Struct, reflect.Array:
+		parts, ok := composite(str)
+		elems := elements(v)
+		if !ok || len(parts) != len(elems) {
+			return fmt.Errorf("cannot parse %q as %s", str, v.Type())
+		}
+		for i := range elems {
+			if !elems[i].CanSet() {
+				return fmt.Errorf("cannot parse %q as %s with unexported fields", str, v.Type())
+			}
+			// quoted parts are unquoted by a kind of an element, interfaces unquote own texts
+			part := parts[i]
+			if strings.HasPrefix(part, `"`) && elems[i].Kind() != reflect.Interface {
+				if part, err = strconv.Unquote(part); err != nil {
+					return fmt.Errorf("cannot parse %q as %s", str, v.Type())
+				}
+			}
+			if err = parse(elems[i], part); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("cannot parse %q as %s", str, v.Type())
+	}
+	return err
+}
+
+// composite key `{a,{b,c}}` parts split by top level commas,
+// commas and braces of quoted parts `{"a,b",c}` are kept
+func composite(str string) ([]string, bool) {
+	if len(str) < 2 || str[0] != '{' || str[len(str)-1] != '}' {
+		return nil, false
+	}
+	var (
+		parts []string
+		depth int
+		start = 1
+	)
+	for i := 1; i < len(str)-1; i++ {
+		if str[i] == '"' {
+			// skip a quoted part with escapes
+			for i++; i < len(str)-1 && str[i] != '"'; i++ {
+				if str[i] == '\\' {
+					i++
+				}
+			}
+			continue
+		}
+		switch str[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, str[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if len(str) > 2 {
+		parts = append(parts, str[start:len(str)-1])
+	}
+	return parts, depth == 0
+}
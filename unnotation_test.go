@@ -0,0 +1,326 @@
+// Copyright (c) 2019, Ivan Eremin. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package anvil
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/iveronanomi/anvil/modifier"
+)
+
+type (
+	Config struct {
+		Name     string `json:"name"`
+		Port     uint16
+		Debug    bool
+		Ratio    float32
+		Started  time.Time
+		Tags     []string
+		Weights  [3]int
+		Limits   map[string]int64
+		Codes    map[int]string
+		Backends []*Backend
+		Primary  *Backend
+		Extra    interface{}
+		hidden   string
+	}
+	Backend struct {
+		Host string
+		Port int
+	}
+)
+
+func TestAnvil_Unnotation_RoundTrip(t *testing.T) {
+	clock, _ := time.Parse(time.RFC3339Nano, "2019-04-22T15:49:32.556091+03:00")
+	expected := Config{
+		Name:     "service",
+		Port:     8080,
+		Debug:    true,
+		Ratio:    .5,
+		Started:  clock,
+		Tags:     []string{"one", "two"},
+		Weights:  [3]int{1, 0, 3},
+		Limits:   map[string]int64{"cpu": 2, "memory": 512},
+		Codes:    map[int]string{-1: "minus", 1: "plus"},
+		Backends: []*Backend{{Host: "a", Port: 1}, {Host: "b", Port: 2}},
+		Primary:  &Backend{Host: "p", Port: 3},
+		Extra:    "extra",
+	}
+	a := &Anvil{Mode: NoSkipEmpty, Glue: "."}
+	a.RegisterModifierFunc(time.Time{}, modifier.Time)
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Config
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !occurred.Started.Equal(expected.Started) {
+		t.Errorf("expected %v, occurred %v", expected.Started, occurred.Started)
+	}
+	occurred.Started = expected.Started
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestAnvil_Unnotation_WithStringValues(t *testing.T) {
+	items := []Item{
+		{Key: "Backend.Host", Value: "localhost"},
+		{Key: "Backend.Port", Value: "5432"},
+	}
+	expected := Backend{Host: "localhost", Port: 5432}
+	var occurred Backend
+
+	err := (&Anvil{Glue: "."}).Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if occurred != expected {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestAnvil_Unnotation_WithMapRoot(t *testing.T) {
+	items := []Item{
+		{Key: "[One]", Value: "Uno"},
+		{Key: "[Two]", Value: "Dos"},
+	}
+	expected := map[string]string{"One": "Uno", "Two": "Dos"}
+	var occurred map[string]string
+
+	err := (&Anvil{Glue: "."}).Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestAnvil_Unnotation_Errors(t *testing.T) {
+	a := &Anvil{Glue: "."}
+	var b Backend
+	cases := []struct {
+		name   string
+		items  []Item
+		target interface{}
+	}{
+		{name: "not a pointer", target: b},
+		{name: "nil pointer", target: (*Backend)(nil)},
+		{name: "another root", items: []Item{{Key: "Config.Port", Value: 1}}, target: &b},
+		{name: "root of a name", items: []Item{{Key: "BackendHost", Value: "h"}}, target: &b},
+		{name: "unknown field", items: []Item{{Key: "Backend.Unknown", Value: 1}}, target: &b},
+		{name: "invalid value", items: []Item{{Key: "Backend.Port", Value: "port"}}, target: &b},
+		{name: "unexpected type", items: []Item{{Key: "Backend.Host", Value: 1}}, target: &b},
+		{name: "unclosed bracket", items: []Item{{Key: "Backend[1", Value: 1}}, target: &b},
+	}
+	for _, c := range cases {
+		if err := a.Unnotation(c.items, c.target); err == nil {
+			t.Errorf("%s: error expected", c.name)
+		}
+	}
+}
+
+func TestAnvil_Unnotation_WithMaxIndex(t *testing.T) {
+	cases := map[string]struct {
+		a   *Anvil
+		key string
+	}{
+		"huge index":  {a: &Anvil{}, key: "[9223372036854775806]"},
+		"default max": {a: &Anvil{}, key: "[1048577]"},
+		"max index":   {a: &Anvil{MaxIndex: 10}, key: "[11]"},
+	}
+	for name, c := range cases {
+		var l []int
+		if err := c.a.Unnotation([]Item{{Key: c.key, Value: 1}}, &l); err == nil {
+			t.Errorf("%s: error expected", name)
+		}
+	}
+	var l []int
+	if err := (&Anvil{MaxIndex: 10}).Unnotation([]Item{{Key: "[10]", Value: 1}}, &l); err != nil || len(l) != 11 {
+		t.Errorf("unexpected error %v of %v", err, l)
+	}
+}
+
+func TestAnvil_Unnotation_WithInterfaceTree(t *testing.T) {
+	type Envelope struct {
+		Meta    interface{}
+		Payload interface{}
+		Kind    fmt.Stringer
+	}
+	v := Envelope{
+		Meta:    map[string]interface{}{"a": 1, "b": []interface{}{"x", nil, "z"}},
+		Payload: Backend{Host: "h", Port: 1},
+	}
+	a := &Anvil{Glue: "."}
+	items, err := a.Notation(v)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Envelope
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	// nested values of empty interfaces are decoded as Unflatten does
+	expected := Envelope{
+		Meta:    v.Meta,
+		Payload: map[string]interface{}{"Host": "h", "Port": 1},
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+
+	cases := map[string]Item{
+		"not empty interface": {Key: "Envelope.Kind.Name", Value: "a"},
+		"conflicting value":   {Key: "Envelope.Meta.a.b", Value: 1},
+	}
+	for name, item := range cases {
+		if err := a.Unnotation([]Item{item}, &occurred); err == nil {
+			t.Errorf("%s: error expected", name)
+		}
+	}
+}
+
+func TestAnvil_Split(t *testing.T) {
+	expected := []Segment{
+		{Name: "Orders"},
+		{Name: "1", Bracket: true},
+		{Name: "Items"},
+		{Name: "sku", Bracket: true},
+		{Name: "Price"},
+	}
+
+	r, err := (&Anvil{Glue: "__"}).Split("__Orders[1]__Items[sku]__Price")
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, r) {
+		t.Errorf("expected %#v, occurred %#v", expected, r)
+	}
+}
+
+func TestAnvil_Unnotation_WithCompositeMapKeys(t *testing.T) {
+	type (
+		Point struct {
+			X, Y int
+		}
+		Grid struct {
+			Cells map[Point]string
+			Lines map[[2]Point]bool
+		}
+	)
+	expected := Grid{
+		Cells: map[Point]string{{X: 1, Y: -1}: "a", {X: 0, Y: 2}: "b"},
+		Lines: map[[2]Point]bool{{{X: 1}, {Y: 1}}: true},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Grid
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}
+
+func TestAnvil_Unnotation_WithComplexMapKeys(t *testing.T) {
+	type Spectrum struct {
+		Wide   map[complex128]int
+		Narrow map[complex64]string
+		Pairs  map[[2]complex128]bool
+	}
+	expected := Spectrum{
+		Wide:   map[complex128]int{1 + 2i: 1, -1.5 - .5i: 2, 0: 3},
+		Narrow: map[complex64]string{.1 + 1e10i: "a"},
+		Pairs:  map[[2]complex128]bool{{1i, -2}: true},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	var occurred Spectrum
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+	if err = a.Unnotation([]Item{{Key: "Spectrum.Wide[(1+2i)x]", Value: 1}}, &occurred); err == nil {
+		t.Error("invalid complex key: error expected")
+	}
+}
+
+func TestAnvil_Unnotation_WithQuotedCompositeMapKeys(t *testing.T) {
+	type Pairs struct {
+		Words map[[2]string]int
+		Any   map[interface{}]int
+		Mixed map[[2]interface{}]int
+	}
+	expected := Pairs{
+		Words: map[[2]string]int{{"a,b", "c"}: 1, {"a", "b,c"}: 2, {`"q"`, "{}"}: 3, {"<nil>", ""}: 4},
+		Any:   map[interface{}]int{nil: 1, "<nil>": 2, `"x"`: 3},
+		Mixed: map[[2]interface{}]int{{nil, "<nil>"}: 1, {"<nil>", "a,b"}: 2},
+	}
+	a := &Anvil{Mode: SkipEmpty, Glue: "."}
+	items, err := a.Notation(expected)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	keys := make(map[string]bool, len(items))
+	for _, item := range items {
+		if keys[item.Key] {
+			t.Errorf("duplicated key %q", item.Key)
+		}
+		keys[item.Key] = true
+	}
+	var occurred Pairs
+
+	err = a.Unnotation(items, &occurred)
+
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	if !reflect.DeepEqual(expected, occurred) {
+		t.Errorf("expected %#v, occurred %#v", expected, occurred)
+	}
+}